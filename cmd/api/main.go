@@ -1,32 +1,127 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"analytics/external"
+	"analytics/internal/api/gql"
+	"analytics/internal/api/grpcapi"
 	"analytics/internal/api/handlers"
 	"analytics/internal/api/routes"
+	"analytics/internal/changes"
+	"analytics/internal/config"
 	"analytics/internal/db"
+	"analytics/internal/domain"
+	"analytics/internal/logging"
+	"analytics/internal/metrics"
+	"analytics/internal/migrations"
+	"analytics/internal/ratelimit"
 	"analytics/internal/repository"
+	"analytics/internal/responsecache"
 	"analytics/internal/service"
+	"analytics/internal/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 )
 
 func main() {
-	err := godotenv.Load("stack.env")
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.SensitiveData); err != nil {
+		fatal("Unable to set up logging", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		fatal("Unable to set up tracing", err)
+	}
+
+	databaseService := db.NewDatabaseService(cfg.Database)
+	pool, err := databaseService.GetPool(context.Background())
+	if err != nil {
+		fatal("Unable to connect to database", err)
+	}
+
+	migrator, err := migrations.NewMigrator(pool)
 	if err != nil {
-		log.Printf("Warning: Error loading .env file: %v\n", err)
+		fatal("Unable to load migrations", err)
 	}
+	if len(cfg.Command) > 0 {
+		err := runMigrate(context.Background(), migrator, cfg.Command[1:], os.Stdout)
+		databaseService.Close()
+		if err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up(context.Background(), 0)
+		for _, migration := range applied {
+			slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			fatal("Unable to migrate database", err)
+		}
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		fatal("Database schema is behind, run migrate up or set DB_AUTO_MIGRATE", err)
+	}
+
+	if err := metrics.RegisterPool(pool); err != nil {
+		fatal("Unable to register pool metrics", err)
+	}
+
+	// Natural language queries get their own pool, connected as the
+	// analytics_query role, so generated SQL neither reads other tables nor
+	// takes the connections of other requests.
+	queryDatabaseConfig := cfg.Database
+	queryDatabaseConfig.URL = cfg.Query.DatabaseURL
+	queryDatabaseService := db.NewDatabaseService(queryDatabaseConfig)
+	queryPool, err := queryDatabaseService.GetPool(context.Background())
+	if err != nil {
+		fatal("Unable to connect to database as the query role", err)
+	}
+
+	openAIService := external.NewOpenAIService(cfg.OpenAI)
 
-	databaseService := &db.DatabaseService{}
-	pool := databaseService.GetPool()
+	// Caches are trusted while the listener receives the notifications of the
+	// transactions and categories triggers.
+	changeListener := changes.NewListener(pool.Config().ConnConfig.Copy())
 
-	transactionRepo := repository.NewTransactionRepository(pool)
-	categoryRepo := repository.NewCategoryRepository(pool)
+	transactionRepo := repository.NewTransactionRepository(pool, changeListener)
+	categoryRepo := repository.NewCategoryRepository(pool, changeListener)
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+	llmUsageRepo := repository.NewLLMUsageRepository(pool)
+	schemaRepo := repository.NewSchemaRepository(pool)
+	webhookRepo := repository.NewWebhookRepository(pool)
+	reportRepo := repository.NewReportRepository(pool)
+	tagRepo := repository.NewTagRepository(pool)
+	merchantRuleRepo := repository.NewMerchantRuleRepository(pool)
+	budgetRepo := repository.NewBudgetRepository(pool)
+	anomalyRepo := repository.NewAnomalyRepository(pool)
+	dataVersionRepo := repository.NewDataVersionRepository(pool, changeListener)
 
 	transactionAnalysisService := service.NewTransactionAnalysisService(
 		transactionRepo,
@@ -35,31 +130,219 @@ func main() {
 
 	typeService := service.NewTypeService(transactionRepo)
 	categoryService := service.NewCategoryService(categoryRepo, transactionRepo)
+	userService := service.NewUserService(transactionRepo, categoryRepo)
+	authService := service.NewAuthService(apiKeyRepo, cfg.Auth.JWTSecret, cfg.Auth.AdminAPIKey)
+	usageService := service.NewUsageService(llmUsageRepo)
+	queryService := service.NewQueryService(pool, queryPool, openAIService, categoryRepo, tagRepo, changeListener)
+	totalsService := service.NewTotalsService(transactionRepo, categoryRepo)
+	tagService := service.NewTagService(tagRepo, transactionRepo)
+	merchantService := service.NewMerchantService(merchantRuleRepo, transactionRepo, changeListener)
+	webhookService := service.NewWebhookService(webhookRepo, external.NewWebhookSender(cfg.Webhook.Timeout), cfg.Webhook.MaxAttempts, cfg.Webhook.PollInterval)
+	budgetService := service.NewBudgetService(budgetRepo, transactionRepo, categoryRepo, webhookService, cfg.Alert.CheckInterval)
+	anomalyService := service.NewAnomalyService(anomalyRepo, transactionRepo, categoryRepo, webhookService, cfg.Alert.CheckInterval)
+	reportPeriods := make([]domain.ReportPeriod, 0, len(cfg.Report.Periods))
+	for _, period := range cfg.Report.Periods {
+		reportPeriods = append(reportPeriods, domain.ReportPeriod(period))
+	}
+	reportService := service.NewReportService(reportRepo, transactionRepo, categoryRepo, budgetService, openAIService, usageService, webhookService, external.NewMailer(cfg.SMTP), service.ReportOptions{
+		Periods:       reportPeriods,
+		CheckInterval: cfg.Report.CheckInterval,
+		Narrative:     cfg.Report.Narrative,
+		EmailTo:       cfg.Report.EmailTo,
+	})
+	healthService := service.NewHealthService(schemaRepo, migrator, openAIService, cfg.Health.CheckLLM, cfg.Health.Timeout)
 
 	transactionHandler := handlers.NewTransactionHandler(transactionRepo, transactionAnalysisService)
 	typeHandler := handlers.NewTypeHandler(typeService)
 	categoryHandler := handlers.NewCategoryHandler(categoryRepo, categoryService)
+	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(authService)
+	usageHandler := handlers.NewUsageHandler(usageService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	queryHandler := handlers.NewQueryHandler(queryService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	changesHandler := handlers.NewChangesHandler(changeListener)
+	tagHandler := handlers.NewTagHandler(tagService)
+	merchantHandler := handlers.NewMerchantHandler(merchantService)
+	budgetHandler := handlers.NewBudgetHandler(budgetService)
+	liveHandler := handlers.NewLiveHandler(changeListener, transactionRepo, totalsService, categoryService, typeService, cfg.HTTP.CORSAllowOrigins)
+	graphqlHandler := gql.NewHandler(gql.Services{
+		TransactionRepo:     transactionRepo,
+		CategoryRepo:        categoryRepo,
+		TransactionAnalysis: transactionAnalysisService,
+		Category:            categoryService,
+		Type:                typeService,
+		User:                userService,
+		Totals:              totalsService,
+		Tag:                 tagService,
+		Merchant:            merchantService,
+	})
 
-	if os.Getenv("GIN_MODE") != "debug" {
-		gin.SetMode(gin.ReleaseMode)
-	}
+	// One limiter for every transport, so a client's /query budget is shared.
+	queryLimiter := ratelimit.New(cfg.Query.RateLimitPerMinute, cfg.Query.RateLimitBurst)
+	responseCache := responsecache.New(cfg.ResponseCache.TTL, cfg.ResponseCache.MaxBytes)
+
+	gin.SetMode(cfg.HTTP.GinMode)
 
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(gin.Recovery(), otelgin.Middleware(tracing.ServiceName))
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Allow all origins for development. Change for production!
+		AllowOrigins:     cfg.HTTP.CORSAllowOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	router.SetTrustedProxies([]string{"172.16.0.0/12", "192.168.0.0/16"})
+	if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
+
+	routes.SetupRoutes(router, routes.Deps{
+		QueryLimiter:       queryLimiter,
+		ResponseCache:      responseCache,
+		DataVersions:       dataVersionRepo,
+		AuthService:        authService,
+		UsageService:       usageService,
+		TransactionHandler: transactionHandler,
+		TypeHandler:        typeHandler,
+		CategoryHandler:    categoryHandler,
+		UserHandler:        userHandler,
+		AuthHandler:        authHandler,
+		UsageHandler:       usageHandler,
+		HealthHandler:      healthHandler,
+		QueryHandler:       queryHandler,
+		GraphQLHandler:     graphqlHandler,
+		WebhookHandler:     webhookHandler,
+		ReportHandler:      reportHandler,
+		ChangesHandler:     changesHandler,
+		LiveHandler:        liveHandler,
+		TagHandler:         tagHandler,
+		MerchantHandler:    merchantHandler,
+		BudgetHandler:      budgetHandler,
+	})
+
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	// End change streams and live connections on shutdown instead of waiting
+	// for clients to leave.
+	server.RegisterOnShutdown(changeListener.Close)
+	shutdownTimeout := cfg.HTTP.ShutdownTimeout
+
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Listening", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	var grpcServer *grpc.Server
+	var grpcHealth *health.Server
+	if cfg.GRPC.Addr != "" {
+		listener, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			fatal("Unable to listen for gRPC", err)
+		}
+		grpcServer, grpcHealth = grpcapi.NewServer(grpcapi.Services{
+			Auth:                authService,
+			Usage:               usageService,
+			TransactionRepo:     transactionRepo,
+			TransactionAnalysis: transactionAnalysisService,
+			Category:            categoryService,
+			Type:                typeService,
+			User:                userService,
+			Query:               queryService,
+		}, queryLimiter)
+		go func() {
+			slog.Info("Serving gRPC", "addr", cfg.GRPC.Addr)
+			serverErr <- grpcServer.Serve(listener)
+		}()
+	}
+
+	// Change notifications, webhook deliveries, budget and anomaly alerts and
+	// scheduled reports run in the background until the servers have drained and nothing new can be
+	// published.
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	for _, run := range []func(context.Context){changeListener.Run, webhookService.Run, budgetService.Run, anomalyService.Run, reportService.Run} {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(backgroundCtx)
+		}()
+	}
+	backgroundStopped := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(backgroundStopped)
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, grpc.ErrServerStopped) {
+			fatal("Server failed", err)
+		}
+	case <-ctx.Done():
+		slog.Info("Shutting down, draining in-flight requests", "timeout", shutdownTimeout)
+	}
+	stop()
 
-	routes.SetupRoutes(router, transactionHandler, typeHandler, categoryHandler)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and wait for in-flight ones, /query calls and
+	// streaming responses included, before tearing down what they depend on.
+	grpcStopped := make(chan struct{})
+	if grpcServer != nil {
+		grpcHealth.Shutdown()
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server did not drain in time", "error", err)
+	}
+	if grpcServer != nil {
+		select {
+		case <-grpcStopped:
+		case <-shutdownCtx.Done():
+			slog.Error("gRPC server did not drain in time")
+			grpcServer.Stop()
+		}
+	}
+
+	stopBackground()
+	select {
+	case <-backgroundStopped:
+	case <-shutdownCtx.Done():
+		slog.Error("Background work did not finish in time")
+	}
+
+	external.CloseIdleConnections()
+	queryDatabaseService.Close()
+	databaseService.Close()
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Unable to flush traces", "error", err)
+	}
+
+	slog.Info("Shutdown complete")
+}
 
-	router.Run("0.0.0.0:1234")
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
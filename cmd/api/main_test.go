@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInvalidConfigExitsWithStatus2 runs main in a child process, as it exits.
+func TestInvalidConfigExitsWithStatus2(t *testing.T) {
+	if os.Getenv("ANALYTICS_TEST_MAIN") == "1" {
+		os.Args = []string{"analytics", "-env-file", os.Getenv("ANALYTICS_TEST_ENV_FILE"), "-db-max-conns=0", "-log-level=loud"}
+		main()
+		return
+	}
+
+	envFile := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(envFile, []byte("OPENAI_API_KEY=sk-test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInvalidConfigExitsWithStatus2$")
+	cmd.Env = []string{"ANALYTICS_TEST_MAIN=1", "ANALYTICS_TEST_ENV_FILE=" + envFile}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Fatalf("exited with %v, want status 2; stderr:\n%s", err, stderr.String())
+	}
+	for _, problem := range []string{"DATABASE_URL is required", "DB_MAX_CONNS must be positive", "LOG_LEVEL must be debug, info, warn or error"} {
+		if !strings.Contains(stderr.String(), problem) {
+			t.Errorf("stderr misses %q:\n%s", problem, stderr.String())
+		}
+	}
+}
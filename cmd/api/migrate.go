@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"analytics/internal/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: migrate status | migrate up [N] | migrate down [N]")
+
+// runMigrate runs the migrate subcommand: status lists every migration, up
+// applies N pending ones (all by default) and down reverts the last N (one
+// by default).
+func runMigrate(ctx context.Context, migrator *migrations.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errMigrateUsage
+	}
+
+	switch args[0] {
+	case "status":
+		if len(args) != 1 {
+			return errMigrateUsage
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	case "up":
+		steps, err := migrateSteps(args, 0)
+		if err != nil {
+			return err
+		}
+		done, err := migrator.Up(ctx, steps)
+		printMigrations(out, "applied", done)
+		return err
+	case "down":
+		steps, err := migrateSteps(args, 1)
+		if err != nil {
+			return err
+		}
+		done, err := migrator.Down(ctx, steps)
+		printMigrations(out, "reverted", done)
+		return err
+	default:
+		return errMigrateUsage
+	}
+}
+
+func migrateSteps(args []string, fallback int) (int, error) {
+	if len(args) == 1 {
+		return fallback, nil
+	}
+	steps, err := strconv.Atoi(args[1])
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("N must be a positive number, got %q", args[1])
+	}
+	return steps, nil
+}
+
+func printMigrations(out io.Writer, verb string, done []migrations.Migration) {
+	if len(done) == 0 {
+		fmt.Fprintf(out, "nothing %s\n", verb)
+		return
+	}
+	for _, migration := range done {
+		fmt.Fprintf(out, "%s %04d_%s\n", verb, migration.Version, migration.Name)
+	}
+}
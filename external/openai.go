@@ -1,45 +1,178 @@
 package external
 
 import (
+	"analytics/internal/config"
+	"analytics/internal/metrics"
+	"analytics/internal/tracing"
 	"context"
-	"log"
-	"os"
-	"sync"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Pricing in USD per million tokens
+type ModelPricing struct {
+	Prompt     float64
+	Completion float64
+}
+
+var modelPricing = map[string]ModelPricing{
+	openai.ChatModelGPT4o: {Prompt: 2.50, Completion: 10.00},
+}
+
+// Usage is what a single completion consumed, as reported by the API
+type Usage struct {
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+	Latency          time.Duration
+}
+
+func (u Usage) TotalTokens() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// EstimatedCost returns the cost of the call in USD, or 0 for unknown models.
+func (u Usage) EstimatedCost() float64 {
+	pricing, ok := modelPricing[u.Model]
+	if !ok {
+		return 0
+	}
+	return (float64(u.PromptTokens)*pricing.Prompt + float64(u.CompletionTokens)*pricing.Completion) / 1_000_000
+}
+
+// httpClient is shared by every OpenAI client so connections are reused
+// across requests and can be closed on shutdown.
+var httpClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+// CloseIdleConnections closes the idle connections kept open to the LLM
+// providers and webhook receivers.
+func CloseIdleConnections() {
+	httpClient.CloseIdleConnections()
+	webhookClient.CloseIdleConnections()
+}
+
 type OpenAIService struct {
 	client openai.Client
-	once   sync.Once
 }
 
-func (o *OpenAIService) GetOpenAIClient() openai.Client {
-	o.once.Do(func() {
-		openAIKey := os.Getenv("OPENAI_API_KEY")
-		if openAIKey == "" {
-			log.Fatalf("OPENAI_API_KEY is not set")
-		}
+func NewOpenAIService(config config.OpenAIConfig) *OpenAIService {
+	return &OpenAIService{
+		client: openai.NewClient(
+			option.WithAPIKey(config.APIKey),
+			option.WithHTTPClient(httpClient),
+		),
+	}
+}
+
+func (o *OpenAIService) Ask(ctx context.Context, prompt string) (string, Usage, error) {
+	model := openai.ChatModelGPT4o
 
-		o.client = openai.NewClient(
-			option.WithAPIKey(openAIKey),
-		)
+	ctx, span := tracing.Start(ctx, "openai.chat.completions",
+		attribute.String("gen_ai.system", "openai"),
+		attribute.String("gen_ai.request.model", model),
+	)
+
+	start := time.Now()
+	chatCompletion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		},
+		Model: model,
 	})
+	latency := time.Since(start)
+	if err != nil {
+		metrics.ObserveLLMCall(model, latency, 0, 0, err)
+		tracing.EndWithError(span, err)
+		return "", Usage{Model: model, Latency: latency}, fmt.Errorf("completion failed: %w", err)
+	}
+
+	usage := Usage{
+		Model:            model,
+		PromptTokens:     chatCompletion.Usage.PromptTokens,
+		CompletionTokens: chatCompletion.Usage.CompletionTokens,
+		Latency:          latency,
+	}
+	metrics.ObserveLLMCall(model, latency, usage.PromptTokens, usage.CompletionTokens, nil)
+	span.SetAttributes(
+		attribute.Int64("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attribute.Int64("gen_ai.usage.output_tokens", usage.CompletionTokens),
+	)
+	defer span.End()
+
+	if len(chatCompletion.Choices) == 0 {
+		return "", usage, fmt.Errorf("completion returned no choices")
+	}
 
-	return o.client
+	return chatCompletion.Choices[0].Message.Content, usage, nil
 }
 
-func (o *OpenAIService) Ask(prompt string) string {
-	chatCompletion, err := o.client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
+// AskStream is Ask for callers that show the answer while it is written:
+// onDelta receives each piece of content as it arrives, and an error from it
+// stops the completion.
+func (o *OpenAIService) AskStream(ctx context.Context, prompt string, onDelta func(string) error) (string, Usage, error) {
+	model := openai.ChatModelGPT4o
+
+	ctx, span := tracing.Start(ctx, "openai.chat.completions",
+		attribute.String("gen_ai.system", "openai"),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Bool("gen_ai.request.stream", true),
+	)
+
+	start := time.Now()
+	stream := o.client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.UserMessage(prompt),
 		},
-		Model: openai.ChatModelGPT4o,
+		Model:         model,
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)},
 	})
+	defer stream.Close()
+
+	usage := Usage{Model: model}
+	var content strings.Builder
+	var err error
+	for stream.Next() {
+		chunk := stream.Current()
+		if chunk.Usage.TotalTokens > 0 {
+			usage.PromptTokens = chunk.Usage.PromptTokens
+			usage.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if err = onDelta(delta); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = stream.Err()
+	}
+	usage.Latency = time.Since(start)
+
+	metrics.ObserveLLMCall(model, usage.Latency, usage.PromptTokens, usage.CompletionTokens, err)
+	span.SetAttributes(
+		attribute.Int64("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attribute.Int64("gen_ai.usage.output_tokens", usage.CompletionTokens),
+	)
+	tracing.EndWithError(span, err)
 	if err != nil {
-		panic(err.Error())
+		return "", usage, fmt.Errorf("completion failed: %w", err)
 	}
 
-	return chatCompletion.Choices[0].Message.Content
+	return content.String(), usage, nil
+}
+
+// Ping checks the provider is reachable and the key is accepted by looking up
+// the model used for completions.
+func (o *OpenAIService) Ping(ctx context.Context) error {
+	_, err := o.client.Models.Get(ctx, openai.ChatModelGPT4o)
+	return err
 }
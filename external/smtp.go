@@ -0,0 +1,119 @@
+package external
+
+import (
+	"analytics/internal/config"
+	"analytics/internal/tracing"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Mailer sends plain text emails through an SMTP server. STARTTLS is used
+// whenever the server offers it, and credentials are only sent over TLS or
+// to localhost, as net/smtp enforces.
+type Mailer struct {
+	config config.SMTPConfig
+}
+
+func NewMailer(config config.SMTPConfig) *Mailer {
+	return &Mailer{config: config}
+}
+
+func (m *Mailer) Send(ctx context.Context, to []string, subject string, body string) error {
+	ctx, span := tracing.Start(ctx, "smtp.send", attribute.Int("smtp.recipients", len(to)))
+
+	err := m.send(ctx, to, subject, body)
+	tracing.EndWithError(span, err)
+	return err
+}
+
+func (m *Mailer) send(ctx context.Context, to []string, subject string, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+
+	host, _, err := net.SplitHostPort(m.config.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.config.From); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("RCPT TO %s failed: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if err := writeMessage(w, m.config.From, to, subject, body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func writeMessage(w io.Writer, from string, to []string, subject string, body string) error {
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+	}
+	if _, err := fmt.Fprintf(w, "%s\r\n\r\n", strings.Join(headers, "\r\n")); err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
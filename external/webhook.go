@@ -0,0 +1,109 @@
+package external
+
+import (
+	"analytics/internal/tracing"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookClient is kept apart from the LLM client so slow receivers never
+// hold connections the LLM calls need.
+var webhookClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+// WebhookRequest is one signed POST to a receiver
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Payload    []byte
+}
+
+type WebhookSender struct {
+	timeout time.Duration
+}
+
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	return &WebhookSender{timeout: timeout}
+}
+
+// Timeout is the longest a single Send can take.
+func (s *WebhookSender) Timeout() time.Duration {
+	return s.timeout
+}
+
+// Send posts the payload and returns the status code of the response, or 0
+// when none was received. Only 2xx responses count as delivered.
+func (s *WebhookSender) Send(ctx context.Context, req WebhookRequest) (int, error) {
+	ctx, span := tracing.Start(ctx, "webhook.send",
+		attribute.String("webhook.event", req.Event),
+		attribute.Int("webhook.delivery_id", req.DeliveryID),
+	)
+
+	statusCode, err := s.send(ctx, req)
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+	tracing.EndWithError(span, err)
+	return statusCode, err
+}
+
+func (s *WebhookSender) send(ctx context.Context, req WebhookRequest) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "analytics-webhooks")
+	httpReq.Header.Set(WebhookEventHeader, req.Event)
+	httpReq.Header.Set(WebhookDeliveryHeader, strconv.Itoa(req.DeliveryID))
+	httpReq.Header.Set(WebhookTimestampHeader, timestamp)
+	httpReq.Header.Set(WebhookSignatureHeader, SignWebhook(req.Secret, timestamp, req.Payload))
+
+	resp, err := webhookClient.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the signature header value of a payload: the hex
+// HMAC-SHA256, keyed with the webhook secret, of "<timestamp>.<payload>".
+// Receivers recompute it and should reject old timestamps to stop replays.
+func SignWebhook(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,539 @@
+// Package dto is the v2 response contract: snake_case fields, null for
+// missing values, dates as YYYY-MM-DD and empty lists as [] rather than null.
+package dto
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"encoding/json"
+	"time"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+type Transaction struct {
+	ID          int         `json:"id"`
+	CategoryID  int         `json:"category_id"`
+	CreatedByID *int        `json:"created_by_id"`
+	Amount      float64     `json:"amount"`
+	Type        domain.Type `json:"type"`
+	Subtype     *string     `json:"subtype"`
+	Description string      `json:"description"`
+	Date        *time.Time  `json:"date"`
+	IsRecurring bool        `json:"is_recurring"`
+	Frequency   *string     `json:"frequency"`
+	StartDate   *string     `json:"start_date"`
+	EndDate     *string     `json:"end_date"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Tags        []string    `json:"tags"`
+}
+
+func NewTransactions(transactions []domain.Transaction) []Transaction {
+	result := make([]Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		tags := t.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		result = append(result, Transaction{
+			ID:          t.ID,
+			CategoryID:  t.CategoryID,
+			CreatedByID: t.CreatedById,
+			Amount:      t.Amount,
+			Type:        t.Type,
+			Subtype:     t.Subtype,
+			Description: t.Description,
+			Date:        t.Date,
+			IsRecurring: t.IsRecurring,
+			Frequency:   t.Frequency,
+			StartDate:   formatDate(t.StartDate),
+			EndDate:     formatDate(t.EndDate),
+			CreatedAt:   t.CreatedAt,
+			UpdatedAt:   t.UpdatedAt,
+			Tags:        tags,
+		})
+	}
+	return result
+}
+
+type Category struct {
+	ID          int        `json:"id"`
+	ParentID    *int       `json:"parent_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Color       string     `json:"color"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+func NewCategories(categories []domain.Category) []Category {
+	result := make([]Category, 0, len(categories))
+	for _, c := range categories {
+		result = append(result, newCategory(c))
+	}
+	return result
+}
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+func NewCategoryTree(nodes []domain.CategoryNode) []CategoryNode {
+	result := make([]CategoryNode, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, CategoryNode{Category: newCategory(n.Category), Children: NewCategoryTree(n.Children)})
+	}
+	return result
+}
+
+func newCategory(c domain.Category) Category {
+	category := Category{
+		ID:          c.ID,
+		ParentID:    c.ParentID,
+		Name:        c.Name,
+		Description: c.Description,
+		Color:       c.Color,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+	if c.DeletedAt.Valid {
+		category.DeletedAt = &c.DeletedAt.Time
+	}
+	return category
+}
+
+type AverageCategory struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Average      float64 `json:"average"`
+}
+
+func NewAverageCategories(averages []service.AverageCategory) []AverageCategory {
+	result := make([]AverageCategory, 0, len(averages))
+	for _, a := range averages {
+		result = append(result, AverageCategory(a))
+	}
+	return result
+}
+
+type AverageType struct {
+	Type    string  `json:"type"`
+	Average float64 `json:"average"`
+}
+
+func NewAverageTypes(averages []service.AverageType) []AverageType {
+	result := make([]AverageType, 0, len(averages))
+	for _, a := range averages {
+		result = append(result, AverageType{Type: a.TypeName, Average: a.Average})
+	}
+	return result
+}
+
+// MonthTotals sums the transactions of one month, overall and by category
+// and type.
+type MonthTotals struct {
+	Month   string          `json:"month"`
+	Income  float64         `json:"income"`
+	Expense float64         `json:"expense"`
+	Totals  []CategoryTotal `json:"totals"`
+}
+
+type CategoryTotal struct {
+	CategoryID int         `json:"category_id"`
+	Type       domain.Type `json:"type"`
+	Amount     float64     `json:"amount"`
+	Count      int         `json:"count"`
+}
+
+// NewMonthTotals expects totals grouped by type, and by category and type.
+// Category totals include their subcategories, so only the totals by type
+// add up to the income and expense.
+func NewMonthTotals(month time.Time, byType []service.Total, totals []service.Total) MonthTotals {
+	result := MonthTotals{Month: month.Format(monthLayout), Totals: make([]CategoryTotal, 0, len(totals))}
+	for _, t := range byType {
+		switch *t.Type {
+		case domain.Income:
+			result.Income += t.Amount
+		case domain.Expense:
+			result.Expense += t.Amount
+		}
+	}
+	for _, t := range totals {
+		result.Totals = append(result.Totals, CategoryTotal{
+			CategoryID: *t.CategoryID,
+			Type:       *t.Type,
+			Amount:     t.Amount,
+			Count:      t.Count,
+		})
+	}
+	return result
+}
+
+type UserCategorySpend struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Total        float64 `json:"total"`
+}
+
+type UserSpending struct {
+	UserID           int                 `json:"user_id"`
+	Expense          float64             `json:"expense"`
+	Income           float64             `json:"income"`
+	TransactionCount int                 `json:"transaction_count"`
+	Categories       []UserCategorySpend `json:"categories"`
+}
+
+func NewUserSpending(spending []service.UserSpending) []UserSpending {
+	result := make([]UserSpending, 0, len(spending))
+	for _, s := range spending {
+		categories := make([]UserCategorySpend, 0, len(s.Categories))
+		for _, c := range s.Categories {
+			categories = append(categories, UserCategorySpend(c))
+		}
+		result = append(result, UserSpending{
+			UserID:           s.UserID,
+			Expense:          s.Expense,
+			Income:           s.Income,
+			TransactionCount: s.TransactionCount,
+			Categories:       categories,
+		})
+	}
+	return result
+}
+
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewTags(tags []domain.Tag) []Tag {
+	result := make([]Tag, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, Tag(t))
+	}
+	return result
+}
+
+type TransactionTags struct {
+	TransactionID int      `json:"transaction_id"`
+	Tags          []string `json:"tags"`
+}
+
+type TagSpending struct {
+	TagName          string     `json:"tag"`
+	Expense          float64    `json:"expense"`
+	Income           float64    `json:"income"`
+	TransactionCount int        `json:"transaction_count"`
+	FirstDate        *time.Time `json:"first_date"`
+	LastDate         *time.Time `json:"last_date"`
+}
+
+func NewTagSpending(spending []service.TagSpending) []TagSpending {
+	result := make([]TagSpending, 0, len(spending))
+	for _, s := range spending {
+		result = append(result, TagSpending(s))
+	}
+	return result
+}
+
+type MerchantRule struct {
+	ID        int                     `json:"id"`
+	Kind      domain.MerchantRuleKind `json:"kind"`
+	Match     string                  `json:"match"`
+	Merchant  string                  `json:"merchant"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+func NewMerchantRules(rules []domain.MerchantRule) []MerchantRule {
+	result := make([]MerchantRule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, MerchantRule(r))
+	}
+	return result
+}
+
+type MerchantSpending struct {
+	Merchant             string     `json:"merchant"`
+	Total                float64    `json:"total"`
+	TransactionCount     int        `json:"transaction_count"`
+	TransactionsPerMonth float64    `json:"transactions_per_month"`
+	AverageTicket        float64    `json:"average_ticket"`
+	FirstSeen            *time.Time `json:"first_seen"`
+	LastSeen             *time.Time `json:"last_seen"`
+}
+
+func NewMerchantSpending(spending []service.MerchantSpending) []MerchantSpending {
+	result := make([]MerchantSpending, 0, len(spending))
+	for _, s := range spending {
+		result = append(result, MerchantSpending(s))
+	}
+	return result
+}
+
+type Quota struct {
+	DailyTokens   *int64   `json:"daily_tokens"`
+	MonthlyTokens *int64   `json:"monthly_tokens"`
+	DailyCost     *float64 `json:"daily_cost_usd"`
+	MonthlyCost   *float64 `json:"monthly_cost_usd"`
+}
+
+type APIKey struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix"`
+	Scopes     []domain.Scope `json:"scopes"`
+	UserID     *int           `json:"user_id"`
+	Quota      Quota          `json:"quota"`
+	CreatedAt  time.Time      `json:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+}
+
+func NewAPIKey(key domain.APIKey) APIKey {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []domain.Scope{}
+	}
+	return APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		UserID:     key.UserID,
+		Quota:      Quota(key.Quota),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func NewAPIKeys(keys []domain.APIKey) []APIKey {
+	result := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, NewAPIKey(key))
+	}
+	return result
+}
+
+// IssuedAPIKey carries the plaintext key, which is never returned again.
+type IssuedAPIKey struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"`
+}
+
+func NewIssuedAPIKey(issued *service.IssuedAPIKey) IssuedAPIKey {
+	return IssuedAPIKey{APIKey: NewAPIKey(issued.APIKey), Key: issued.Key}
+}
+
+type LLMUsageAggregate struct {
+	Group            string  `json:"group,omitempty"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost_usd"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+}
+
+type LLMUsageReport struct {
+	From       string              `json:"from"`
+	To         string              `json:"to"`
+	Total      LLMUsageAggregate   `json:"total"`
+	ByDay      []LLMUsageAggregate `json:"by_day"`
+	ByEndpoint []LLMUsageAggregate `json:"by_endpoint"`
+	ByModel    []LLMUsageAggregate `json:"by_model"`
+}
+
+// NewLLMUsageReport renders a report over [From, To) with an inclusive to date.
+func NewLLMUsageReport(report *service.LLMUsageReport) LLMUsageReport {
+	return LLMUsageReport{
+		From:       report.From.Format(dateLayout),
+		To:         report.To.AddDate(0, 0, -1).Format(dateLayout),
+		Total:      LLMUsageAggregate(report.Total),
+		ByDay:      newLLMUsageAggregates(report.ByDay),
+		ByEndpoint: newLLMUsageAggregates(report.ByEndpoint),
+		ByModel:    newLLMUsageAggregates(report.ByModel),
+	}
+}
+
+func newLLMUsageAggregates(aggregates []repository.LLMUsageAggregate) []LLMUsageAggregate {
+	result := make([]LLMUsageAggregate, 0, len(aggregates))
+	for _, a := range aggregates {
+		result = append(result, LLMUsageAggregate(a))
+	}
+	return result
+}
+
+type Webhook struct {
+	ID        int                `json:"id"`
+	URL       string             `json:"url"`
+	Events    []domain.EventType `json:"events"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func NewWebhook(webhook domain.Webhook) Webhook {
+	events := webhook.Events
+	if events == nil {
+		events = []domain.EventType{}
+	}
+	return Webhook{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    events,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func NewWebhooks(webhooks []domain.Webhook) []Webhook {
+	result := make([]Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, NewWebhook(webhook))
+	}
+	return result
+}
+
+// CreatedWebhook carries the signing secret, which is never returned again.
+type CreatedWebhook struct {
+	Webhook Webhook `json:"webhook"`
+	Secret  string  `json:"secret"`
+}
+
+func NewCreatedWebhook(webhook *domain.Webhook) CreatedWebhook {
+	return CreatedWebhook{Webhook: NewWebhook(*webhook), Secret: webhook.Secret}
+}
+
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	WebhookID      int                   `json:"webhook_id"`
+	EventID        string                `json:"event_id"`
+	EventType      domain.EventType      `json:"event_type"`
+	Status         domain.DeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code"`
+	LastError      *string               `json:"last_error"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	Payload        json.RawMessage       `json:"payload"`
+}
+
+// NewWebhookDelivery only sets next_attempt_at while another attempt is due.
+func NewWebhookDelivery(delivery domain.WebhookDelivery) WebhookDelivery {
+	var nextAttemptAt *time.Time
+	if delivery.Status == domain.DeliveryPending {
+		nextAttemptAt = &delivery.NextAttemptAt
+	}
+	return WebhookDelivery{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		Payload:        delivery.Payload,
+	}
+}
+
+func NewWebhookDeliveries(deliveries []domain.WebhookDelivery) []WebhookDelivery {
+	result := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, NewWebhookDelivery(delivery))
+	}
+	return result
+}
+
+// Report has the last day of its period as period_end.
+type Report struct {
+	ID          int                  `json:"id"`
+	Period      domain.ReportPeriod  `json:"period"`
+	PeriodStart string               `json:"period_start"`
+	PeriodEnd   string               `json:"period_end"`
+	Summary     domain.ReportSummary `json:"summary"`
+	Narrative   *string              `json:"narrative"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+func NewReport(report domain.Report) Report {
+	return Report{
+		ID:          report.ID,
+		Period:      report.Period,
+		PeriodStart: report.PeriodStart.Format(dateLayout),
+		PeriodEnd:   report.PeriodEnd.AddDate(0, 0, -1).Format(dateLayout),
+		Summary:     report.Summary,
+		Narrative:   report.Narrative,
+		CreatedAt:   report.CreatedAt,
+	}
+}
+
+func NewReports(reports []domain.Report) []Report {
+	result := make([]Report, 0, len(reports))
+	for _, report := range reports {
+		result = append(result, NewReport(report))
+	}
+	return result
+}
+
+type Budget struct {
+	ID         int       `json:"id"`
+	CategoryID int       `json:"category_id"`
+	Amount     float64   `json:"amount"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewBudgets(budgets []domain.Budget) []Budget {
+	result := make([]Budget, 0, len(budgets))
+	for _, b := range budgets {
+		result = append(result, Budget(b))
+	}
+	return result
+}
+
+type BudgetStatus struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Amount       float64 `json:"amount"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"`
+	Exceeded     bool    `json:"exceeded"`
+}
+
+func NewBudgetStatuses(statuses []service.BudgetStatus) []BudgetStatus {
+	result := make([]BudgetStatus, 0, len(statuses))
+	for _, s := range statuses {
+		result = append(result, BudgetStatus{
+			CategoryID:   s.Budget.CategoryID,
+			CategoryName: s.CategoryName,
+			Amount:       s.Budget.Amount,
+			Spent:        s.Spent,
+			Remaining:    s.Remaining,
+			Exceeded:     s.Exceeded,
+		})
+	}
+	return result
+}
+
+type QueryAnswer struct {
+	Answer string `json:"answer"`
+}
+
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	date := t.Format(dateLayout)
+	return &date
+}
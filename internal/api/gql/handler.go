@@ -0,0 +1,89 @@
+// Package gql serves a GraphQL schema over transactions, categories and
+// aggregates, on top of the same services as the REST handlers.
+package gql
+
+import (
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"context"
+	_ "embed"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxDepth bounds how deeply queries can nest
+const maxDepth = 8
+
+// Services are what the GraphQL schema is built on
+type Services struct {
+	TransactionRepo     *repository.TransactionRepository
+	CategoryRepo        *repository.CategoryRepository
+	TransactionAnalysis *service.TransactionAnalysisService
+	Category            *service.CategoryService
+	Type                *service.TypeService
+	User                *service.UserService
+	Totals              *service.TotalsService
+	Tag                 *service.TagService
+	Merchant            *service.MerchantService
+}
+
+type Handler struct {
+	schema       *graphql.Schema
+	categoryRepo *repository.CategoryRepository
+}
+
+func NewHandler(services Services) *Handler {
+	return &Handler{
+		schema:       graphql.MustParseSchema(schema, &resolver{services: services}, graphql.MaxDepth(maxDepth)),
+		categoryRepo: services.CategoryRepo,
+	}
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Serve executes a GraphQL request. Errors in the query are reported in the
+// response body with a 200, as GraphQL clients expect.
+func (h *Handler) Serve(c *gin.Context) {
+	var req request
+	if err := c.ShouldBindJSON(&req); err != nil || req.Query == "" {
+		middleware.AbortWithError(c, middleware.BadRequest("expected a JSON body with a query"))
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), principalKey{}, middleware.GetPrincipal(c))
+	ctx = withCategoryLoader(ctx, newCategoryLoader(h.categoryRepo))
+
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+type principalKey struct{}
+
+func principalFromContext(ctx context.Context) *domain.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*domain.Principal)
+	return principal
+}
+
+// publicError keeps the message of errors caused by the request and hides
+// everything else, which is logged instead.
+func publicError(ctx context.Context, err error) error {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) || errors.Is(err, domain.ErrOutsideScope) {
+		return err
+	}
+
+	slog.ErrorContext(ctx, "GraphQL resolver failed", "component", "gql", "error", err)
+	return errors.New("internal server error")
+}
@@ -0,0 +1,51 @@
+package gql
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+type categoryLoaderKey struct{}
+
+// categoryLoader batches the category lookups of one request into a single
+// query and caches them for the rest of the request.
+type categoryLoader = dataloader.Loader[int, *domain.Category]
+
+func newCategoryLoader(categoryRepo *repository.CategoryRepository) *categoryLoader {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, ids []int) []*dataloader.Result[*domain.Category] {
+		results := make([]*dataloader.Result[*domain.Category], len(ids))
+
+		categories, err := categoryRepo.GetCategoriesByIDs(ctx, ids)
+		if err != nil {
+			for i := range results {
+				results[i] = &dataloader.Result[*domain.Category]{Error: fmt.Errorf("failed to load categories: %w", err)}
+			}
+			return results
+		}
+
+		byID := make(map[int]*domain.Category, len(categories))
+		for i := range categories {
+			byID[categories[i].ID] = &categories[i]
+		}
+		for i, id := range ids {
+			results[i] = &dataloader.Result[*domain.Category]{Data: byID[id]}
+		}
+		return results
+	}, dataloader.WithWait[int, *domain.Category](2*time.Millisecond))
+}
+
+func withCategoryLoader(ctx context.Context, loader *categoryLoader) context.Context {
+	return context.WithValue(ctx, categoryLoaderKey{}, loader)
+}
+
+// loadCategory queues id for the next batch. Resolvers of lists call it for
+// every item before returning, so the whole list costs one query however the
+// fields end up being resolved.
+func loadCategory(ctx context.Context, id int) dataloader.Thunk[*domain.Category] {
+	return ctx.Value(categoryLoaderKey{}).(*categoryLoader).Load(ctx, id)
+}
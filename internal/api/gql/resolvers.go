@@ -0,0 +1,496 @@
+package gql
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxLimit caps how many transactions a single query can list
+const maxLimit = 1000
+
+type resolver struct {
+	services Services
+}
+
+type transactionFilterInput struct {
+	UserID      *int32
+	Type        *string
+	Subtype     *string
+	CategoryIDs *[]int32
+	From        *graphql.Time
+	To          *graphql.Time
+	Tags        *[]string
+}
+
+type filterArgs struct {
+	Filter *transactionFilterInput
+}
+
+// transactionFilter converts the filter argument and scopes it to what the
+// caller may see.
+func transactionFilter(ctx context.Context, input *transactionFilterInput) (repository.TransactionFilter, error) {
+	var filter repository.TransactionFilter
+	if input != nil {
+		if input.UserID != nil {
+			if *input.UserID <= 0 {
+				return filter, &service.ValidationError{Message: "invalid userId"}
+			}
+			userID := int(*input.UserID)
+			filter.CreatedByID = &userID
+		}
+		if input.Type != nil {
+			txType := domain.Type(strings.ToLower(*input.Type))
+			if txType != domain.Income && txType != domain.Expense {
+				return filter, &service.ValidationError{Message: "type must be income or expense"}
+			}
+			filter.Type = &txType
+		}
+		if input.Subtype != nil && *input.Subtype != "" {
+			filter.Subtype = input.Subtype
+		}
+		if input.CategoryIDs != nil {
+			filter.CategoryIDs = make([]int, 0, len(*input.CategoryIDs))
+			for _, id := range *input.CategoryIDs {
+				filter.CategoryIDs = append(filter.CategoryIDs, int(id))
+			}
+		}
+		if input.From != nil {
+			filter.From = &input.From.Time
+		}
+		if input.To != nil {
+			filter.To = &input.To.Time
+		}
+		if input.Tags != nil {
+			filter.Tags = make([]string, 0, len(*input.Tags))
+			for _, tag := range *input.Tags {
+				filter.Tags = append(filter.Tags, domain.NormalizeTagName(tag))
+			}
+		}
+	}
+
+	userID, err := principalFromContext(ctx).ScopeUserID(filter.CreatedByID)
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedByID = userID
+	return filter, nil
+}
+
+func (r *resolver) Transactions(ctx context.Context, args struct {
+	Filter *transactionFilterInput
+	Limit  int32
+	Offset int32
+}) ([]*transactionResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	limit, offset := int(args.Limit), int(args.Offset)
+	if limit < 0 || limit > maxLimit || offset < 0 {
+		return nil, &service.ValidationError{Message: "limit must be between 0 and 1000 and offset must not be negative"}
+	}
+
+	transactions, err := r.services.TransactionRepo.GetLatestTransactions(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*transactionResolver, 0, len(transactions))
+	for _, t := range transactions {
+		result = append(result, &transactionResolver{t: t, category: loadCategory(ctx, t.CategoryID)})
+	}
+	return result, nil
+}
+
+func (r *resolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	categories, err := r.services.CategoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*categoryResolver, 0, len(categories))
+	for i := range categories {
+		result = append(result, &categoryResolver{c: &categories[i]})
+	}
+	return result, nil
+}
+
+func (r *resolver) Category(ctx context.Context, args struct{ ID int32 }) (*categoryResolver, error) {
+	return resolveCategory(ctx, loadCategory(ctx, int(args.ID)))
+}
+
+func (r *resolver) CategoryAverages(ctx context.Context, args filterArgs) ([]*categoryAverageResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	averages, err := r.services.Category.GetAverageByCategory(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*categoryAverageResolver, 0, len(averages))
+	for _, a := range averages {
+		result = append(result, &categoryAverageResolver{a: a, category: loadCategory(ctx, a.CategoryID)})
+	}
+	return result, nil
+}
+
+func (r *resolver) MonthlyCategoryAverages(ctx context.Context, args filterArgs) ([]*monthlyCategoryAverageResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	averages, err := r.services.TransactionAnalysis.GetAverageSpendByCategory(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*monthlyCategoryAverageResolver, 0, len(averages))
+	for _, a := range averages {
+		result = append(result, &monthlyCategoryAverageResolver{a: a, category: loadCategory(ctx, a.CategoryID)})
+	}
+	return result, nil
+}
+
+func (r *resolver) TypeAverages(ctx context.Context, args filterArgs) ([]*typeAverageResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	averages, err := r.services.Type.GetAverageByType(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*typeAverageResolver, 0, len(averages))
+	for _, a := range averages {
+		result = append(result, &typeAverageResolver{a: a})
+	}
+	return result, nil
+}
+
+func (r *resolver) UserSpending(ctx context.Context, args filterArgs) ([]*userSpendingResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	spending, err := r.services.User.GetSpendingByUser(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*userSpendingResolver, 0, len(spending))
+	for _, s := range spending {
+		user := &userSpendingResolver{s: s}
+		for _, c := range s.Categories {
+			user.categories = append(user.categories, &userCategorySpendResolver{c: c, category: loadCategory(ctx, c.CategoryID)})
+		}
+		result = append(result, user)
+	}
+	return result, nil
+}
+
+func (r *resolver) Totals(ctx context.Context, args struct {
+	Filter  *transactionFilterInput
+	GroupBy []string
+	Period  *string
+}) ([]*totalResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	var groupBy []service.Dimension
+	for _, dimension := range args.GroupBy {
+		groupBy = append(groupBy, service.Dimension(strings.ToLower(dimension)))
+	}
+	var period service.Period
+	if args.Period != nil {
+		period = service.Period(strings.ToLower(*args.Period))
+	}
+
+	totals, err := r.services.Totals.GetTotals(ctx, filter, groupBy, period)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*totalResolver, 0, len(totals))
+	for _, t := range totals {
+		total := &totalResolver{t: t}
+		if t.CategoryID != nil {
+			total.category = loadCategory(ctx, *t.CategoryID)
+		}
+		result = append(result, total)
+	}
+	return result, nil
+}
+
+func (r *resolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	tags, err := r.services.Tag.GetTags(ctx)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*tagResolver, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, &tagResolver{t: t})
+	}
+	return result, nil
+}
+
+func (r *resolver) TagSpending(ctx context.Context, args filterArgs) ([]*tagSpendingResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	spending, err := r.services.Tag.GetSpendingByTag(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*tagSpendingResolver, 0, len(spending))
+	for _, s := range spending {
+		result = append(result, &tagSpendingResolver{s: s})
+	}
+	return result, nil
+}
+
+func (r *resolver) MerchantSpending(ctx context.Context, args filterArgs) ([]*merchantSpendingResolver, error) {
+	filter, err := transactionFilter(ctx, args.Filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	spending, err := r.services.Merchant.GetSpendingByMerchant(ctx, filter)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+
+	result := make([]*merchantSpendingResolver, 0, len(spending))
+	for _, s := range spending {
+		result = append(result, &merchantSpendingResolver{s: s})
+	}
+	return result, nil
+}
+
+func resolveCategory(ctx context.Context, thunk dataloader.Thunk[*domain.Category]) (*categoryResolver, error) {
+	if thunk == nil {
+		return nil, nil
+	}
+	category, err := thunk()
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	if category == nil {
+		return nil, nil
+	}
+	return &categoryResolver{c: category}, nil
+}
+
+type transactionResolver struct {
+	t        domain.Transaction
+	category dataloader.Thunk[*domain.Category]
+}
+
+func (r *transactionResolver) ID() int32           { return int32(r.t.ID) }
+func (r *transactionResolver) Amount() float64     { return r.t.Amount }
+func (r *transactionResolver) Type() string        { return enumValue(string(r.t.Type)) }
+func (r *transactionResolver) Description() string { return r.t.Description }
+func (r *transactionResolver) Date() *graphql.Time { return optionalTime(r.t.Date) }
+func (r *transactionResolver) CreatedByID() *int32 { return optionalInt32(r.t.CreatedById) }
+func (r *transactionResolver) IsRecurring() bool   { return r.t.IsRecurring }
+func (r *transactionResolver) Subtype() *string    { return r.t.Subtype }
+func (r *transactionResolver) Frequency() *string  { return r.t.Frequency }
+func (r *transactionResolver) StartDate() *graphql.Time {
+	return optionalTime(r.t.StartDate)
+}
+func (r *transactionResolver) EndDate() *graphql.Time { return optionalTime(r.t.EndDate) }
+func (r *transactionResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.t.CreatedAt}
+}
+func (r *transactionResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.t.UpdatedAt}
+}
+func (r *transactionResolver) Tags() []string {
+	if r.t.Tags == nil {
+		return []string{}
+	}
+	return r.t.Tags
+}
+func (r *transactionResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return resolveCategory(ctx, r.category)
+}
+
+type categoryResolver struct {
+	c *domain.Category
+}
+
+func (r *categoryResolver) ID() int32           { return int32(r.c.ID) }
+func (r *categoryResolver) ParentID() *int32    { return optionalInt32(r.c.ParentID) }
+func (r *categoryResolver) Name() string        { return r.c.Name }
+func (r *categoryResolver) Description() string { return r.c.Description }
+func (r *categoryResolver) Color() string       { return r.c.Color }
+func (r *categoryResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.c.CreatedAt}
+}
+func (r *categoryResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.c.UpdatedAt}
+}
+func (r *categoryResolver) Parent(ctx context.Context) (*categoryResolver, error) {
+	if r.c.ParentID == nil {
+		return nil, nil
+	}
+	return resolveCategory(ctx, loadCategory(ctx, *r.c.ParentID))
+}
+func (r *categoryResolver) DeletedAt() *graphql.Time {
+	if !r.c.DeletedAt.Valid {
+		return nil
+	}
+	return &graphql.Time{Time: r.c.DeletedAt.Time}
+}
+
+type categoryAverageResolver struct {
+	a        service.AverageCategory
+	category dataloader.Thunk[*domain.Category]
+}
+
+func (r *categoryAverageResolver) CategoryID() int32 { return int32(r.a.CategoryID) }
+func (r *categoryAverageResolver) Average() float64  { return r.a.Average }
+func (r *categoryAverageResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return resolveCategory(ctx, r.category)
+}
+
+type monthlyCategoryAverageResolver struct {
+	a        service.AverageCategorySpendByMonth
+	category dataloader.Thunk[*domain.Category]
+}
+
+func (r *monthlyCategoryAverageResolver) CategoryID() int32     { return int32(r.a.CategoryID) }
+func (r *monthlyCategoryAverageResolver) Month() graphql.Time   { return graphql.Time{Time: r.a.Month} }
+func (r *monthlyCategoryAverageResolver) AverageSpend() float64 { return r.a.AverageSpend }
+func (r *monthlyCategoryAverageResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return resolveCategory(ctx, r.category)
+}
+
+type typeAverageResolver struct {
+	a service.AverageType
+}
+
+func (r *typeAverageResolver) Type() string     { return enumValue(r.a.TypeName) }
+func (r *typeAverageResolver) Average() float64 { return r.a.Average }
+
+type userSpendingResolver struct {
+	s          service.UserSpending
+	categories []*userCategorySpendResolver
+}
+
+func (r *userSpendingResolver) UserID() int32           { return int32(r.s.UserID) }
+func (r *userSpendingResolver) Expense() float64        { return r.s.Expense }
+func (r *userSpendingResolver) Income() float64         { return r.s.Income }
+func (r *userSpendingResolver) TransactionCount() int32 { return int32(r.s.TransactionCount) }
+func (r *userSpendingResolver) Categories() []*userCategorySpendResolver {
+	if r.categories == nil {
+		return []*userCategorySpendResolver{}
+	}
+	return r.categories
+}
+
+type userCategorySpendResolver struct {
+	c        service.UserCategorySpend
+	category dataloader.Thunk[*domain.Category]
+}
+
+func (r *userCategorySpendResolver) CategoryID() int32 { return int32(r.c.CategoryID) }
+func (r *userCategorySpendResolver) Total() float64    { return r.c.Total }
+func (r *userCategorySpendResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return resolveCategory(ctx, r.category)
+}
+
+type totalResolver struct {
+	t        service.Total
+	category dataloader.Thunk[*domain.Category]
+}
+
+func (r *totalResolver) PeriodStart() *graphql.Time { return optionalTime(r.t.PeriodStart) }
+func (r *totalResolver) CategoryID() *int32         { return optionalInt32(r.t.CategoryID) }
+func (r *totalResolver) Subtype() *string           { return r.t.Subtype }
+func (r *totalResolver) UserID() *int32             { return optionalInt32(r.t.UserID) }
+func (r *totalResolver) Amount() float64            { return r.t.Amount }
+func (r *totalResolver) Count() int32               { return int32(r.t.Count) }
+func (r *totalResolver) Type() *string {
+	if r.t.Type == nil {
+		return nil
+	}
+	value := enumValue(string(*r.t.Type))
+	return &value
+}
+func (r *totalResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return resolveCategory(ctx, r.category)
+}
+
+type tagResolver struct {
+	t domain.Tag
+}
+
+func (r *tagResolver) ID() int32    { return int32(r.t.ID) }
+func (r *tagResolver) Name() string { return r.t.Name }
+func (r *tagResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.t.CreatedAt}
+}
+
+type tagSpendingResolver struct {
+	s service.TagSpending
+}
+
+func (r *tagSpendingResolver) Tag() string              { return r.s.TagName }
+func (r *tagSpendingResolver) Expense() float64         { return r.s.Expense }
+func (r *tagSpendingResolver) Income() float64          { return r.s.Income }
+func (r *tagSpendingResolver) TransactionCount() int32  { return int32(r.s.TransactionCount) }
+func (r *tagSpendingResolver) FirstDate() *graphql.Time { return optionalTime(r.s.FirstDate) }
+func (r *tagSpendingResolver) LastDate() *graphql.Time  { return optionalTime(r.s.LastDate) }
+
+type merchantSpendingResolver struct {
+	s service.MerchantSpending
+}
+
+func (r *merchantSpendingResolver) Merchant() string              { return r.s.Merchant }
+func (r *merchantSpendingResolver) Total() float64                { return r.s.Total }
+func (r *merchantSpendingResolver) TransactionCount() int32       { return int32(r.s.TransactionCount) }
+func (r *merchantSpendingResolver) TransactionsPerMonth() float64 { return r.s.TransactionsPerMonth }
+func (r *merchantSpendingResolver) AverageTicket() float64        { return r.s.AverageTicket }
+func (r *merchantSpendingResolver) FirstSeen() *graphql.Time      { return optionalTime(r.s.FirstSeen) }
+func (r *merchantSpendingResolver) LastSeen() *graphql.Time       { return optionalTime(r.s.LastSeen) }
+
+// enumValue renders a database value such as "expense" as a GraphQL enum value.
+func enumValue(value string) string {
+	return strings.ToUpper(value)
+}
+
+func optionalInt32(value *int) *int32 {
+	if value == nil {
+		return nil
+	}
+	v := int32(*value)
+	return &v
+}
+
+func optionalTime(value *time.Time) *graphql.Time {
+	if value == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *value}
+}
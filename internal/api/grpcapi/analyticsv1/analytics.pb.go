@@ -0,0 +1,1388 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: analytics/v1/analytics.proto
+
+package analyticsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TransactionType int32
+
+const (
+	TransactionType_TRANSACTION_TYPE_UNSPECIFIED TransactionType = 0
+	TransactionType_TRANSACTION_TYPE_INCOME      TransactionType = 1
+	TransactionType_TRANSACTION_TYPE_EXPENSE     TransactionType = 2
+)
+
+// Enum value maps for TransactionType.
+var (
+	TransactionType_name = map[int32]string{
+		0: "TRANSACTION_TYPE_UNSPECIFIED",
+		1: "TRANSACTION_TYPE_INCOME",
+		2: "TRANSACTION_TYPE_EXPENSE",
+	}
+	TransactionType_value = map[string]int32{
+		"TRANSACTION_TYPE_UNSPECIFIED": 0,
+		"TRANSACTION_TYPE_INCOME":      1,
+		"TRANSACTION_TYPE_EXPENSE":     2,
+	}
+)
+
+func (x TransactionType) Enum() *TransactionType {
+	p := new(TransactionType)
+	*p = x
+	return p
+}
+
+func (x TransactionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransactionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_analytics_v1_analytics_proto_enumTypes[0].Descriptor()
+}
+
+func (TransactionType) Type() protoreflect.EnumType {
+	return &file_analytics_v1_analytics_proto_enumTypes[0]
+}
+
+func (x TransactionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransactionType.Descriptor instead.
+func (TransactionType) EnumDescriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{0}
+}
+
+type QueryStage int32
+
+const (
+	QueryStage_QUERY_STAGE_UNSPECIFIED       QueryStage = 0
+	QueryStage_QUERY_STAGE_GENERATING_QUERY  QueryStage = 1
+	QueryStage_QUERY_STAGE_RUNNING_QUERY     QueryStage = 2
+	QueryStage_QUERY_STAGE_ANALYZING_RESULTS QueryStage = 3
+)
+
+// Enum value maps for QueryStage.
+var (
+	QueryStage_name = map[int32]string{
+		0: "QUERY_STAGE_UNSPECIFIED",
+		1: "QUERY_STAGE_GENERATING_QUERY",
+		2: "QUERY_STAGE_RUNNING_QUERY",
+		3: "QUERY_STAGE_ANALYZING_RESULTS",
+	}
+	QueryStage_value = map[string]int32{
+		"QUERY_STAGE_UNSPECIFIED":       0,
+		"QUERY_STAGE_GENERATING_QUERY":  1,
+		"QUERY_STAGE_RUNNING_QUERY":     2,
+		"QUERY_STAGE_ANALYZING_RESULTS": 3,
+	}
+)
+
+func (x QueryStage) Enum() *QueryStage {
+	p := new(QueryStage)
+	*p = x
+	return p
+}
+
+func (x QueryStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueryStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_analytics_v1_analytics_proto_enumTypes[1].Descriptor()
+}
+
+func (QueryStage) Type() protoreflect.EnumType {
+	return &file_analytics_v1_analytics_proto_enumTypes[1]
+}
+
+func (x QueryStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueryStage.Descriptor instead.
+func (QueryStage) EnumDescriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{1}
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CategoryId    int64                  `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CreatedById   *int64                 `protobuf:"varint,3,opt,name=created_by_id,json=createdById,proto3,oneof" json:"created_by_id,omitempty"`
+	Amount        float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Type          TransactionType        `protobuf:"varint,5,opt,name=type,proto3,enum=analytics.v1.TransactionType" json:"type,omitempty"`
+	Subtype       *string                `protobuf:"bytes,6,opt,name=subtype,proto3,oneof" json:"subtype,omitempty"`
+	Description   string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=date,proto3" json:"date,omitempty"`
+	IsRecurring   bool                   `protobuf:"varint,9,opt,name=is_recurring,json=isRecurring,proto3" json:"is_recurring,omitempty"`
+	Frequency     *string                `protobuf:"bytes,10,opt,name=frequency,proto3,oneof" json:"frequency,omitempty"`
+	StartDate     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Transaction) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transaction) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Transaction) GetCreatedById() int64 {
+	if x != nil && x.CreatedById != nil {
+		return *x.CreatedById
+	}
+	return 0
+}
+
+func (x *Transaction) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transaction) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func (x *Transaction) GetSubtype() string {
+	if x != nil && x.Subtype != nil {
+		return *x.Subtype
+	}
+	return ""
+}
+
+func (x *Transaction) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Transaction) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Transaction) GetIsRecurring() bool {
+	if x != nil {
+		return x.IsRecurring
+	}
+	return false
+}
+
+func (x *Transaction) GetFrequency() string {
+	if x != nil && x.Frequency != nil {
+		return *x.Frequency
+	}
+	return ""
+}
+
+func (x *Transaction) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Transaction) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *Transaction) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Transaction) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *int64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListTransactionsRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type ListTransactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsResponse) Reset() {
+	*x = ListTransactionsResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsResponse) ProtoMessage() {}
+
+func (x *ListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type CategoryAverage struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId   int64                  `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CategoryName string                 `protobuf:"bytes,2,opt,name=category_name,json=categoryName,proto3" json:"category_name,omitempty"`
+	// Average of the monthly totals
+	Average       float64 `protobuf:"fixed64,3,opt,name=average,proto3" json:"average,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryAverage) Reset() {
+	*x = CategoryAverage{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryAverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryAverage) ProtoMessage() {}
+
+func (x *CategoryAverage) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryAverage.ProtoReflect.Descriptor instead.
+func (*CategoryAverage) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CategoryAverage) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *CategoryAverage) GetCategoryName() string {
+	if x != nil {
+		return x.CategoryName
+	}
+	return ""
+}
+
+func (x *CategoryAverage) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+type GetCategoryAveragesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *int64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryAveragesRequest) Reset() {
+	*x = GetCategoryAveragesRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryAveragesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryAveragesRequest) ProtoMessage() {}
+
+func (x *GetCategoryAveragesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryAveragesRequest.ProtoReflect.Descriptor instead.
+func (*GetCategoryAveragesRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetCategoryAveragesRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type GetCategoryAveragesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Averages      []*CategoryAverage     `protobuf:"bytes,1,rep,name=averages,proto3" json:"averages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryAveragesResponse) Reset() {
+	*x = GetCategoryAveragesResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryAveragesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryAveragesResponse) ProtoMessage() {}
+
+func (x *GetCategoryAveragesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryAveragesResponse.ProtoReflect.Descriptor instead.
+func (*GetCategoryAveragesResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCategoryAveragesResponse) GetAverages() []*CategoryAverage {
+	if x != nil {
+		return x.Averages
+	}
+	return nil
+}
+
+type MonthlyCategoryAverage struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId   int64                  `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CategoryName string                 `protobuf:"bytes,2,opt,name=category_name,json=categoryName,proto3" json:"category_name,omitempty"`
+	// First day of the month, UTC
+	Month         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=month,proto3" json:"month,omitempty"`
+	AverageSpend  float64                `protobuf:"fixed64,4,opt,name=average_spend,json=averageSpend,proto3" json:"average_spend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MonthlyCategoryAverage) Reset() {
+	*x = MonthlyCategoryAverage{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MonthlyCategoryAverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonthlyCategoryAverage) ProtoMessage() {}
+
+func (x *MonthlyCategoryAverage) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonthlyCategoryAverage.ProtoReflect.Descriptor instead.
+func (*MonthlyCategoryAverage) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MonthlyCategoryAverage) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *MonthlyCategoryAverage) GetCategoryName() string {
+	if x != nil {
+		return x.CategoryName
+	}
+	return ""
+}
+
+func (x *MonthlyCategoryAverage) GetMonth() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Month
+	}
+	return nil
+}
+
+func (x *MonthlyCategoryAverage) GetAverageSpend() float64 {
+	if x != nil {
+		return x.AverageSpend
+	}
+	return 0
+}
+
+type GetMonthlyCategoryAveragesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *int64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMonthlyCategoryAveragesRequest) Reset() {
+	*x = GetMonthlyCategoryAveragesRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMonthlyCategoryAveragesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMonthlyCategoryAveragesRequest) ProtoMessage() {}
+
+func (x *GetMonthlyCategoryAveragesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMonthlyCategoryAveragesRequest.ProtoReflect.Descriptor instead.
+func (*GetMonthlyCategoryAveragesRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetMonthlyCategoryAveragesRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type GetMonthlyCategoryAveragesResponse struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Averages      []*MonthlyCategoryAverage `protobuf:"bytes,1,rep,name=averages,proto3" json:"averages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMonthlyCategoryAveragesResponse) Reset() {
+	*x = GetMonthlyCategoryAveragesResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMonthlyCategoryAveragesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMonthlyCategoryAveragesResponse) ProtoMessage() {}
+
+func (x *GetMonthlyCategoryAveragesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMonthlyCategoryAveragesResponse.ProtoReflect.Descriptor instead.
+func (*GetMonthlyCategoryAveragesResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetMonthlyCategoryAveragesResponse) GetAverages() []*MonthlyCategoryAverage {
+	if x != nil {
+		return x.Averages
+	}
+	return nil
+}
+
+type TypeAverage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  TransactionType        `protobuf:"varint,1,opt,name=type,proto3,enum=analytics.v1.TransactionType" json:"type,omitempty"`
+	// Average of the monthly totals
+	Average       float64 `protobuf:"fixed64,2,opt,name=average,proto3" json:"average,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypeAverage) Reset() {
+	*x = TypeAverage{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypeAverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypeAverage) ProtoMessage() {}
+
+func (x *TypeAverage) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypeAverage.ProtoReflect.Descriptor instead.
+func (*TypeAverage) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TypeAverage) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func (x *TypeAverage) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+type GetTypeAveragesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *int64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTypeAveragesRequest) Reset() {
+	*x = GetTypeAveragesRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTypeAveragesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTypeAveragesRequest) ProtoMessage() {}
+
+func (x *GetTypeAveragesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTypeAveragesRequest.ProtoReflect.Descriptor instead.
+func (*GetTypeAveragesRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetTypeAveragesRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type GetTypeAveragesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Averages      []*TypeAverage         `protobuf:"bytes,1,rep,name=averages,proto3" json:"averages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTypeAveragesResponse) Reset() {
+	*x = GetTypeAveragesResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTypeAveragesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTypeAveragesResponse) ProtoMessage() {}
+
+func (x *GetTypeAveragesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTypeAveragesResponse.ProtoReflect.Descriptor instead.
+func (*GetTypeAveragesResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetTypeAveragesResponse) GetAverages() []*TypeAverage {
+	if x != nil {
+		return x.Averages
+	}
+	return nil
+}
+
+type UserCategorySpend struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId    int64                  `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CategoryName  string                 `protobuf:"bytes,2,opt,name=category_name,json=categoryName,proto3" json:"category_name,omitempty"`
+	Total         float64                `protobuf:"fixed64,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserCategorySpend) Reset() {
+	*x = UserCategorySpend{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCategorySpend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCategorySpend) ProtoMessage() {}
+
+func (x *UserCategorySpend) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCategorySpend.ProtoReflect.Descriptor instead.
+func (*UserCategorySpend) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UserCategorySpend) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *UserCategorySpend) GetCategoryName() string {
+	if x != nil {
+		return x.CategoryName
+	}
+	return ""
+}
+
+func (x *UserCategorySpend) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type UserSpending struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Expense          float64                `protobuf:"fixed64,2,opt,name=expense,proto3" json:"expense,omitempty"`
+	Income           float64                `protobuf:"fixed64,3,opt,name=income,proto3" json:"income,omitempty"`
+	TransactionCount int64                  `protobuf:"varint,4,opt,name=transaction_count,json=transactionCount,proto3" json:"transaction_count,omitempty"`
+	Categories       []*UserCategorySpend   `protobuf:"bytes,5,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UserSpending) Reset() {
+	*x = UserSpending{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserSpending) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserSpending) ProtoMessage() {}
+
+func (x *UserSpending) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserSpending.ProtoReflect.Descriptor instead.
+func (*UserSpending) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserSpending) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserSpending) GetExpense() float64 {
+	if x != nil {
+		return x.Expense
+	}
+	return 0
+}
+
+func (x *UserSpending) GetIncome() float64 {
+	if x != nil {
+		return x.Income
+	}
+	return 0
+}
+
+func (x *UserSpending) GetTransactionCount() int64 {
+	if x != nil {
+		return x.TransactionCount
+	}
+	return 0
+}
+
+func (x *UserSpending) GetCategories() []*UserCategorySpend {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type GetUserSpendingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *int64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserSpendingRequest) Reset() {
+	*x = GetUserSpendingRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserSpendingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserSpendingRequest) ProtoMessage() {}
+
+func (x *GetUserSpendingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserSpendingRequest.ProtoReflect.Descriptor instead.
+func (*GetUserSpendingRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetUserSpendingRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type GetUserSpendingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserSpending        `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserSpendingResponse) Reset() {
+	*x = GetUserSpendingResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserSpendingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserSpendingResponse) ProtoMessage() {}
+
+func (x *GetUserSpendingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserSpendingResponse.ProtoReflect.Descriptor instead.
+func (*GetUserSpendingResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetUserSpendingResponse) GetUsers() []*UserSpending {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Question      string                 `protobuf:"bytes,1,opt,name=question,proto3" json:"question,omitempty"`
+	UserId        *int64                 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *QueryRequest) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetUserId() int64 {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return 0
+}
+
+type QueryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*QueryResponse_Stage
+	//	*QueryResponse_AnswerDelta
+	Event         isQueryResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_analytics_v1_analytics_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_v1_analytics_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_v1_analytics_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *QueryResponse) GetEvent() isQueryResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetStage() QueryStage {
+	if x != nil {
+		if x, ok := x.Event.(*QueryResponse_Stage); ok {
+			return x.Stage
+		}
+	}
+	return QueryStage_QUERY_STAGE_UNSPECIFIED
+}
+
+func (x *QueryResponse) GetAnswerDelta() string {
+	if x != nil {
+		if x, ok := x.Event.(*QueryResponse_AnswerDelta); ok {
+			return x.AnswerDelta
+		}
+	}
+	return ""
+}
+
+type isQueryResponse_Event interface {
+	isQueryResponse_Event()
+}
+
+type QueryResponse_Stage struct {
+	Stage QueryStage `protobuf:"varint,1,opt,name=stage,proto3,enum=analytics.v1.QueryStage,oneof"`
+}
+
+type QueryResponse_AnswerDelta struct {
+	// The next piece of the answer; concatenated they form the whole answer
+	AnswerDelta string `protobuf:"bytes,2,opt,name=answer_delta,json=answerDelta,proto3,oneof"`
+}
+
+func (*QueryResponse_Stage) isQueryResponse_Event() {}
+
+func (*QueryResponse_AnswerDelta) isQueryResponse_Event() {}
+
+var File_analytics_v1_analytics_proto protoreflect.FileDescriptor
+
+const file_analytics_v1_analytics_proto_rawDesc = "" +
+	"\n" +
+	"\x1canalytics/v1/analytics.proto\x12\fanalytics.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfd\x04\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\x12'\n" +
+	"\rcreated_by_id\x18\x03 \x01(\x03H\x00R\vcreatedById\x88\x01\x01\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x121\n" +
+	"\x04type\x18\x05 \x01(\x0e2\x1d.analytics.v1.TransactionTypeR\x04type\x12\x1d\n" +
+	"\asubtype\x18\x06 \x01(\tH\x01R\asubtype\x88\x01\x01\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12.\n" +
+	"\x04date\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12!\n" +
+	"\fis_recurring\x18\t \x01(\bR\visRecurring\x12!\n" +
+	"\tfrequency\x18\n" +
+	" \x01(\tH\x02R\tfrequency\x88\x01\x01\x129\n" +
+	"\n" +
+	"start_date\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\x129\n" +
+	"\n" +
+	"created_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x10\n" +
+	"\x0e_created_by_idB\n" +
+	"\n" +
+	"\b_subtypeB\f\n" +
+	"\n" +
+	"_frequency\"C\n" +
+	"\x17ListTransactionsRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"Y\n" +
+	"\x18ListTransactionsResponse\x12=\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x19.analytics.v1.TransactionR\ftransactions\"q\n" +
+	"\x0fCategoryAverage\x12\x1f\n" +
+	"\vcategory_id\x18\x01 \x01(\x03R\n" +
+	"categoryId\x12#\n" +
+	"\rcategory_name\x18\x02 \x01(\tR\fcategoryName\x12\x18\n" +
+	"\aaverage\x18\x03 \x01(\x01R\aaverage\"F\n" +
+	"\x1aGetCategoryAveragesRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"X\n" +
+	"\x1bGetCategoryAveragesResponse\x129\n" +
+	"\baverages\x18\x01 \x03(\v2\x1d.analytics.v1.CategoryAverageR\baverages\"\xb5\x01\n" +
+	"\x16MonthlyCategoryAverage\x12\x1f\n" +
+	"\vcategory_id\x18\x01 \x01(\x03R\n" +
+	"categoryId\x12#\n" +
+	"\rcategory_name\x18\x02 \x01(\tR\fcategoryName\x120\n" +
+	"\x05month\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05month\x12#\n" +
+	"\raverage_spend\x18\x04 \x01(\x01R\faverageSpend\"M\n" +
+	"!GetMonthlyCategoryAveragesRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"f\n" +
+	"\"GetMonthlyCategoryAveragesResponse\x12@\n" +
+	"\baverages\x18\x01 \x03(\v2$.analytics.v1.MonthlyCategoryAverageR\baverages\"Z\n" +
+	"\vTypeAverage\x121\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1d.analytics.v1.TransactionTypeR\x04type\x12\x18\n" +
+	"\aaverage\x18\x02 \x01(\x01R\aaverage\"B\n" +
+	"\x16GetTypeAveragesRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"P\n" +
+	"\x17GetTypeAveragesResponse\x125\n" +
+	"\baverages\x18\x01 \x03(\v2\x19.analytics.v1.TypeAverageR\baverages\"o\n" +
+	"\x11UserCategorySpend\x12\x1f\n" +
+	"\vcategory_id\x18\x01 \x01(\x03R\n" +
+	"categoryId\x12#\n" +
+	"\rcategory_name\x18\x02 \x01(\tR\fcategoryName\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x01R\x05total\"\xc7\x01\n" +
+	"\fUserSpending\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x18\n" +
+	"\aexpense\x18\x02 \x01(\x01R\aexpense\x12\x16\n" +
+	"\x06income\x18\x03 \x01(\x01R\x06income\x12+\n" +
+	"\x11transaction_count\x18\x04 \x01(\x03R\x10transactionCount\x12?\n" +
+	"\n" +
+	"categories\x18\x05 \x03(\v2\x1f.analytics.v1.UserCategorySpendR\n" +
+	"categories\"B\n" +
+	"\x16GetUserSpendingRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"K\n" +
+	"\x17GetUserSpendingResponse\x120\n" +
+	"\x05users\x18\x01 \x03(\v2\x1a.analytics.v1.UserSpendingR\x05users\"T\n" +
+	"\fQueryRequest\x12\x1a\n" +
+	"\bquestion\x18\x01 \x01(\tR\bquestion\x12\x1c\n" +
+	"\auser_id\x18\x02 \x01(\x03H\x00R\x06userId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_id\"o\n" +
+	"\rQueryResponse\x120\n" +
+	"\x05stage\x18\x01 \x01(\x0e2\x18.analytics.v1.QueryStageH\x00R\x05stage\x12#\n" +
+	"\fanswer_delta\x18\x02 \x01(\tH\x00R\vanswerDeltaB\a\n" +
+	"\x05event*n\n" +
+	"\x0fTransactionType\x12 \n" +
+	"\x1cTRANSACTION_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17TRANSACTION_TYPE_INCOME\x10\x01\x12\x1c\n" +
+	"\x18TRANSACTION_TYPE_EXPENSE\x10\x02*\x8d\x01\n" +
+	"\n" +
+	"QueryStage\x12\x1b\n" +
+	"\x17QUERY_STAGE_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cQUERY_STAGE_GENERATING_QUERY\x10\x01\x12\x1d\n" +
+	"\x19QUERY_STAGE_RUNNING_QUERY\x10\x02\x12!\n" +
+	"\x1dQUERY_STAGE_ANALYZING_RESULTS\x10\x032\xe6\x04\n" +
+	"\x10AnalyticsService\x12a\n" +
+	"\x10ListTransactions\x12%.analytics.v1.ListTransactionsRequest\x1a&.analytics.v1.ListTransactionsResponse\x12j\n" +
+	"\x13GetCategoryAverages\x12(.analytics.v1.GetCategoryAveragesRequest\x1a).analytics.v1.GetCategoryAveragesResponse\x12\x7f\n" +
+	"\x1aGetMonthlyCategoryAverages\x12/.analytics.v1.GetMonthlyCategoryAveragesRequest\x1a0.analytics.v1.GetMonthlyCategoryAveragesResponse\x12^\n" +
+	"\x0fGetTypeAverages\x12$.analytics.v1.GetTypeAveragesRequest\x1a%.analytics.v1.GetTypeAveragesResponse\x12^\n" +
+	"\x0fGetUserSpending\x12$.analytics.v1.GetUserSpendingRequest\x1a%.analytics.v1.GetUserSpendingResponse\x12B\n" +
+	"\x05Query\x12\x1a.analytics.v1.QueryRequest\x1a\x1b.analytics.v1.QueryResponse0\x01B8Z6analytics/internal/api/grpcapi/analyticsv1;analyticsv1b\x06proto3"
+
+var (
+	file_analytics_v1_analytics_proto_rawDescOnce sync.Once
+	file_analytics_v1_analytics_proto_rawDescData []byte
+)
+
+func file_analytics_v1_analytics_proto_rawDescGZIP() []byte {
+	file_analytics_v1_analytics_proto_rawDescOnce.Do(func() {
+		file_analytics_v1_analytics_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_analytics_v1_analytics_proto_rawDesc), len(file_analytics_v1_analytics_proto_rawDesc)))
+	})
+	return file_analytics_v1_analytics_proto_rawDescData
+}
+
+var file_analytics_v1_analytics_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_analytics_v1_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_analytics_v1_analytics_proto_goTypes = []any{
+	(TransactionType)(0),                       // 0: analytics.v1.TransactionType
+	(QueryStage)(0),                            // 1: analytics.v1.QueryStage
+	(*Transaction)(nil),                        // 2: analytics.v1.Transaction
+	(*ListTransactionsRequest)(nil),            // 3: analytics.v1.ListTransactionsRequest
+	(*ListTransactionsResponse)(nil),           // 4: analytics.v1.ListTransactionsResponse
+	(*CategoryAverage)(nil),                    // 5: analytics.v1.CategoryAverage
+	(*GetCategoryAveragesRequest)(nil),         // 6: analytics.v1.GetCategoryAveragesRequest
+	(*GetCategoryAveragesResponse)(nil),        // 7: analytics.v1.GetCategoryAveragesResponse
+	(*MonthlyCategoryAverage)(nil),             // 8: analytics.v1.MonthlyCategoryAverage
+	(*GetMonthlyCategoryAveragesRequest)(nil),  // 9: analytics.v1.GetMonthlyCategoryAveragesRequest
+	(*GetMonthlyCategoryAveragesResponse)(nil), // 10: analytics.v1.GetMonthlyCategoryAveragesResponse
+	(*TypeAverage)(nil),                        // 11: analytics.v1.TypeAverage
+	(*GetTypeAveragesRequest)(nil),             // 12: analytics.v1.GetTypeAveragesRequest
+	(*GetTypeAveragesResponse)(nil),            // 13: analytics.v1.GetTypeAveragesResponse
+	(*UserCategorySpend)(nil),                  // 14: analytics.v1.UserCategorySpend
+	(*UserSpending)(nil),                       // 15: analytics.v1.UserSpending
+	(*GetUserSpendingRequest)(nil),             // 16: analytics.v1.GetUserSpendingRequest
+	(*GetUserSpendingResponse)(nil),            // 17: analytics.v1.GetUserSpendingResponse
+	(*QueryRequest)(nil),                       // 18: analytics.v1.QueryRequest
+	(*QueryResponse)(nil),                      // 19: analytics.v1.QueryResponse
+	(*timestamppb.Timestamp)(nil),              // 20: google.protobuf.Timestamp
+}
+var file_analytics_v1_analytics_proto_depIdxs = []int32{
+	0,  // 0: analytics.v1.Transaction.type:type_name -> analytics.v1.TransactionType
+	20, // 1: analytics.v1.Transaction.date:type_name -> google.protobuf.Timestamp
+	20, // 2: analytics.v1.Transaction.start_date:type_name -> google.protobuf.Timestamp
+	20, // 3: analytics.v1.Transaction.end_date:type_name -> google.protobuf.Timestamp
+	20, // 4: analytics.v1.Transaction.created_at:type_name -> google.protobuf.Timestamp
+	20, // 5: analytics.v1.Transaction.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 6: analytics.v1.ListTransactionsResponse.transactions:type_name -> analytics.v1.Transaction
+	5,  // 7: analytics.v1.GetCategoryAveragesResponse.averages:type_name -> analytics.v1.CategoryAverage
+	20, // 8: analytics.v1.MonthlyCategoryAverage.month:type_name -> google.protobuf.Timestamp
+	8,  // 9: analytics.v1.GetMonthlyCategoryAveragesResponse.averages:type_name -> analytics.v1.MonthlyCategoryAverage
+	0,  // 10: analytics.v1.TypeAverage.type:type_name -> analytics.v1.TransactionType
+	11, // 11: analytics.v1.GetTypeAveragesResponse.averages:type_name -> analytics.v1.TypeAverage
+	14, // 12: analytics.v1.UserSpending.categories:type_name -> analytics.v1.UserCategorySpend
+	15, // 13: analytics.v1.GetUserSpendingResponse.users:type_name -> analytics.v1.UserSpending
+	1,  // 14: analytics.v1.QueryResponse.stage:type_name -> analytics.v1.QueryStage
+	3,  // 15: analytics.v1.AnalyticsService.ListTransactions:input_type -> analytics.v1.ListTransactionsRequest
+	6,  // 16: analytics.v1.AnalyticsService.GetCategoryAverages:input_type -> analytics.v1.GetCategoryAveragesRequest
+	9,  // 17: analytics.v1.AnalyticsService.GetMonthlyCategoryAverages:input_type -> analytics.v1.GetMonthlyCategoryAveragesRequest
+	12, // 18: analytics.v1.AnalyticsService.GetTypeAverages:input_type -> analytics.v1.GetTypeAveragesRequest
+	16, // 19: analytics.v1.AnalyticsService.GetUserSpending:input_type -> analytics.v1.GetUserSpendingRequest
+	18, // 20: analytics.v1.AnalyticsService.Query:input_type -> analytics.v1.QueryRequest
+	4,  // 21: analytics.v1.AnalyticsService.ListTransactions:output_type -> analytics.v1.ListTransactionsResponse
+	7,  // 22: analytics.v1.AnalyticsService.GetCategoryAverages:output_type -> analytics.v1.GetCategoryAveragesResponse
+	10, // 23: analytics.v1.AnalyticsService.GetMonthlyCategoryAverages:output_type -> analytics.v1.GetMonthlyCategoryAveragesResponse
+	13, // 24: analytics.v1.AnalyticsService.GetTypeAverages:output_type -> analytics.v1.GetTypeAveragesResponse
+	17, // 25: analytics.v1.AnalyticsService.GetUserSpending:output_type -> analytics.v1.GetUserSpendingResponse
+	19, // 26: analytics.v1.AnalyticsService.Query:output_type -> analytics.v1.QueryResponse
+	21, // [21:27] is the sub-list for method output_type
+	15, // [15:21] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_analytics_v1_analytics_proto_init() }
+func file_analytics_v1_analytics_proto_init() {
+	if File_analytics_v1_analytics_proto != nil {
+		return
+	}
+	file_analytics_v1_analytics_proto_msgTypes[0].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[1].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[4].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[7].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[10].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[14].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[16].OneofWrappers = []any{}
+	file_analytics_v1_analytics_proto_msgTypes[17].OneofWrappers = []any{
+		(*QueryResponse_Stage)(nil),
+		(*QueryResponse_AnswerDelta)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_analytics_v1_analytics_proto_rawDesc), len(file_analytics_v1_analytics_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_analytics_v1_analytics_proto_goTypes,
+		DependencyIndexes: file_analytics_v1_analytics_proto_depIdxs,
+		EnumInfos:         file_analytics_v1_analytics_proto_enumTypes,
+		MessageInfos:      file_analytics_v1_analytics_proto_msgTypes,
+	}.Build()
+	File_analytics_v1_analytics_proto = out.File
+	file_analytics_v1_analytics_proto_goTypes = nil
+	file_analytics_v1_analytics_proto_depIdxs = nil
+}
@@ -0,0 +1,327 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: analytics/v1/analytics.proto
+
+package analyticsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnalyticsService_ListTransactions_FullMethodName           = "/analytics.v1.AnalyticsService/ListTransactions"
+	AnalyticsService_GetCategoryAverages_FullMethodName        = "/analytics.v1.AnalyticsService/GetCategoryAverages"
+	AnalyticsService_GetMonthlyCategoryAverages_FullMethodName = "/analytics.v1.AnalyticsService/GetMonthlyCategoryAverages"
+	AnalyticsService_GetTypeAverages_FullMethodName            = "/analytics.v1.AnalyticsService/GetTypeAverages"
+	AnalyticsService_GetUserSpending_FullMethodName            = "/analytics.v1.AnalyticsService/GetUserSpending"
+	AnalyticsService_Query_FullMethodName                      = "/analytics.v1.AnalyticsService/Query"
+)
+
+// AnalyticsServiceClient is the client API for AnalyticsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AnalyticsService exposes the same data as the REST API. Calls are
+// authenticated with an "authorization: Bearer <key or JWT>" or "x-api-key"
+// metadata entry and need the same scopes as their REST counterparts.
+type AnalyticsServiceClient interface {
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	GetCategoryAverages(ctx context.Context, in *GetCategoryAveragesRequest, opts ...grpc.CallOption) (*GetCategoryAveragesResponse, error)
+	GetMonthlyCategoryAverages(ctx context.Context, in *GetMonthlyCategoryAveragesRequest, opts ...grpc.CallOption) (*GetMonthlyCategoryAveragesResponse, error)
+	GetTypeAverages(ctx context.Context, in *GetTypeAveragesRequest, opts ...grpc.CallOption) (*GetTypeAveragesResponse, error)
+	GetUserSpending(ctx context.Context, in *GetUserSpendingRequest, opts ...grpc.CallOption) (*GetUserSpendingResponse, error)
+	// Query answers a natural language question, streaming the stages it goes
+	// through and then the answer as it is written.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueryResponse], error)
+}
+
+type analyticsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyticsServiceClient(cc grpc.ClientConnInterface) AnalyticsServiceClient {
+	return &analyticsServiceClient{cc}
+}
+
+func (c *analyticsServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetCategoryAverages(ctx context.Context, in *GetCategoryAveragesRequest, opts ...grpc.CallOption) (*GetCategoryAveragesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCategoryAveragesResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetCategoryAverages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetMonthlyCategoryAverages(ctx context.Context, in *GetMonthlyCategoryAveragesRequest, opts ...grpc.CallOption) (*GetMonthlyCategoryAveragesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMonthlyCategoryAveragesResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetMonthlyCategoryAverages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetTypeAverages(ctx context.Context, in *GetTypeAveragesRequest, opts ...grpc.CallOption) (*GetTypeAveragesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTypeAveragesResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetTypeAverages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetUserSpending(ctx context.Context, in *GetUserSpendingRequest, opts ...grpc.CallOption) (*GetUserSpendingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserSpendingResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetUserSpending_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnalyticsService_ServiceDesc.Streams[0], AnalyticsService_Query_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[QueryRequest, QueryResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyticsService_QueryClient = grpc.ServerStreamingClient[QueryResponse]
+
+// AnalyticsServiceServer is the server API for AnalyticsService service.
+// All implementations must embed UnimplementedAnalyticsServiceServer
+// for forward compatibility.
+//
+// AnalyticsService exposes the same data as the REST API. Calls are
+// authenticated with an "authorization: Bearer <key or JWT>" or "x-api-key"
+// metadata entry and need the same scopes as their REST counterparts.
+type AnalyticsServiceServer interface {
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	GetCategoryAverages(context.Context, *GetCategoryAveragesRequest) (*GetCategoryAveragesResponse, error)
+	GetMonthlyCategoryAverages(context.Context, *GetMonthlyCategoryAveragesRequest) (*GetMonthlyCategoryAveragesResponse, error)
+	GetTypeAverages(context.Context, *GetTypeAveragesRequest) (*GetTypeAveragesResponse, error)
+	GetUserSpending(context.Context, *GetUserSpendingRequest) (*GetUserSpendingResponse, error)
+	// Query answers a natural language question, streaming the stages it goes
+	// through and then the answer as it is written.
+	Query(*QueryRequest, grpc.ServerStreamingServer[QueryResponse]) error
+	mustEmbedUnimplementedAnalyticsServiceServer()
+}
+
+// UnimplementedAnalyticsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnalyticsServiceServer struct{}
+
+func (UnimplementedAnalyticsServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetCategoryAverages(context.Context, *GetCategoryAveragesRequest) (*GetCategoryAveragesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCategoryAverages not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetMonthlyCategoryAverages(context.Context, *GetMonthlyCategoryAveragesRequest) (*GetMonthlyCategoryAveragesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetMonthlyCategoryAverages not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetTypeAverages(context.Context, *GetTypeAveragesRequest) (*GetTypeAveragesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTypeAverages not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetUserSpending(context.Context, *GetUserSpendingRequest) (*GetUserSpendingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetUserSpending not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) Query(*QueryRequest, grpc.ServerStreamingServer[QueryResponse]) error {
+	return status.Error(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) mustEmbedUnimplementedAnalyticsServiceServer() {}
+func (UnimplementedAnalyticsServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeAnalyticsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyticsServiceServer will
+// result in compilation errors.
+type UnsafeAnalyticsServiceServer interface {
+	mustEmbedUnimplementedAnalyticsServiceServer()
+}
+
+func RegisterAnalyticsServiceServer(s grpc.ServiceRegistrar, srv AnalyticsServiceServer) {
+	// If the following call panics, it indicates UnimplementedAnalyticsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnalyticsService_ServiceDesc, srv)
+}
+
+func _AnalyticsService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetCategoryAverages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryAveragesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetCategoryAverages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetCategoryAverages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetCategoryAverages(ctx, req.(*GetCategoryAveragesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetMonthlyCategoryAverages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMonthlyCategoryAveragesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetMonthlyCategoryAverages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetMonthlyCategoryAverages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetMonthlyCategoryAverages(ctx, req.(*GetMonthlyCategoryAveragesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetTypeAverages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTypeAveragesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetTypeAverages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetTypeAverages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetTypeAverages(ctx, req.(*GetTypeAveragesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetUserSpending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserSpendingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetUserSpending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetUserSpending_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetUserSpending(ctx, req.(*GetUserSpendingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_Query_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServiceServer).Query(m, &grpc.GenericServerStream[QueryRequest, QueryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyticsService_QueryServer = grpc.ServerStreamingServer[QueryResponse]
+
+// AnalyticsService_ServiceDesc is the grpc.ServiceDesc for AnalyticsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyticsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "analytics.v1.AnalyticsService",
+	HandlerType: (*AnalyticsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTransactions",
+			Handler:    _AnalyticsService_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GetCategoryAverages",
+			Handler:    _AnalyticsService_GetCategoryAverages_Handler,
+		},
+		{
+			MethodName: "GetMonthlyCategoryAverages",
+			Handler:    _AnalyticsService_GetMonthlyCategoryAverages_Handler,
+		},
+		{
+			MethodName: "GetTypeAverages",
+			Handler:    _AnalyticsService_GetTypeAverages_Handler,
+		},
+		{
+			MethodName: "GetUserSpending",
+			Handler:    _AnalyticsService_GetUserSpending_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Query",
+			Handler:       _AnalyticsService_Query_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "analytics/v1/analytics.proto",
+}
@@ -0,0 +1,162 @@
+package grpcapi
+
+import (
+	"analytics/internal/api/grpcapi/analyticsv1"
+	"analytics/internal/domain"
+	"analytics/internal/logging"
+	"analytics/internal/service"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDKey = "x-request-id"
+
+// methodScopes is the scope each analytics method requires. Analytics methods
+// missing from it are refused; health and reflection need no credentials.
+var methodScopes = map[string]domain.Scope{
+	analyticsv1.AnalyticsService_ListTransactions_FullMethodName:           domain.ScopeReadAnalytics,
+	analyticsv1.AnalyticsService_GetCategoryAverages_FullMethodName:        domain.ScopeReadAnalytics,
+	analyticsv1.AnalyticsService_GetMonthlyCategoryAverages_FullMethodName: domain.ScopeReadAnalytics,
+	analyticsv1.AnalyticsService_GetTypeAverages_FullMethodName:            domain.ScopeReadAnalytics,
+	analyticsv1.AnalyticsService_GetUserSpending_FullMethodName:            domain.ScopeReadAnalytics,
+	analyticsv1.AnalyticsService_Query_FullMethodName:                      domain.ScopeRunQuery,
+}
+
+type principalKey struct{}
+
+func principalFromContext(ctx context.Context) *domain.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*domain.Principal)
+	return principal
+}
+
+// authenticate checks the credentials in the metadata, like middleware.Auth,
+// and the scope the method needs, like middleware.RequireScope.
+func authenticate(ctx context.Context, authService *service.AuthService, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, "/"+analyticsv1.AnalyticsService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not available")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var credential string
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		credential = values[0]
+	} else if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			credential = strings.TrimSpace(token)
+		}
+	}
+
+	principal, err := authService.Authenticate(ctx, credential)
+	if errors.Is(err, service.ErrUnauthenticated) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to authenticate request", "component", "grpcapi.authenticate", "error", err)
+		return nil, status.Error(codes.Internal, "failed to authenticate request")
+	}
+	if !principal.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing scope "+string(scope))
+	}
+
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+func authUnary(authService *service.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authService, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStream(authService *service.AuthService) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authService, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream replaces the context of a stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestID propagates the caller's x-request-id, or a new random one,
+// like middleware.RequestID.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && len(values[0]) <= 128 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		requestID = hex.EncodeToString(b)
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	slog.LogAttrs(ctx, level, "rpc",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
+
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	response, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return response, err
+}
+
+func logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(stream.Context())
+	err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
@@ -0,0 +1,329 @@
+// Package grpcapi serves the analytics API over gRPC, on top of the same
+// services as the REST handlers.
+package grpcapi
+
+import (
+	"analytics/internal/api/grpcapi/analyticsv1"
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"analytics/internal/ratelimit"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Services are what the gRPC API is built on
+type Services struct {
+	Auth                *service.AuthService
+	Usage               *service.UsageService
+	TransactionRepo     *repository.TransactionRepository
+	TransactionAnalysis *service.TransactionAnalysisService
+	Category            *service.CategoryService
+	Type                *service.TypeService
+	User                *service.UserService
+	Query               *service.QueryService
+}
+
+type Server struct {
+	analyticsv1.UnimplementedAnalyticsServiceServer
+	services     Services
+	queryLimiter *ratelimit.Limiter
+}
+
+// NewServer builds a gRPC server with the analytics, health and reflection
+// services registered. Calls are traced and counted in the Prometheus metrics
+// like HTTP requests. The returned health server reports SERVING until it is
+// told otherwise on shutdown.
+func NewServer(services Services, queryLimiter *ratelimit.Limiter) (*grpc.Server, *health.Server) {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.GRPCServer.UnaryServerInterceptor(), logUnary, authUnary(services.Auth)),
+		grpc.ChainStreamInterceptor(metrics.GRPCServer.StreamServerInterceptor(), logStream, authStream(services.Auth)),
+	)
+
+	analyticsv1.RegisterAnalyticsServiceServer(server, &Server{services: services, queryLimiter: queryLimiter})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(analyticsv1.AnalyticsService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	metrics.GRPCServer.InitializeMetrics(server)
+
+	return server, healthServer
+}
+
+func (s *Server) ListTransactions(ctx context.Context, req *analyticsv1.ListTransactionsRequest) (*analyticsv1.ListTransactionsResponse, error) {
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	transactions, err := s.services.TransactionRepo.GetTransactions(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	response := &analyticsv1.ListTransactionsResponse{}
+	for _, t := range transactions {
+		response.Transactions = append(response.Transactions, &analyticsv1.Transaction{
+			Id:          int64(t.ID),
+			CategoryId:  int64(t.CategoryID),
+			CreatedById: optionalInt64(t.CreatedById),
+			Amount:      t.Amount,
+			Type:        transactionType(string(t.Type)),
+			Subtype:     t.Subtype,
+			Description: t.Description,
+			Date:        optionalTimestamp(t.Date),
+			IsRecurring: t.IsRecurring,
+			Frequency:   t.Frequency,
+			StartDate:   optionalTimestamp(t.StartDate),
+			EndDate:     optionalTimestamp(t.EndDate),
+			CreatedAt:   timestamppb.New(t.CreatedAt),
+			UpdatedAt:   timestamppb.New(t.UpdatedAt),
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetCategoryAverages(ctx context.Context, req *analyticsv1.GetCategoryAveragesRequest) (*analyticsv1.GetCategoryAveragesResponse, error) {
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	averages, err := s.services.Category.GetAverageByCategory(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	response := &analyticsv1.GetCategoryAveragesResponse{}
+	for _, a := range averages {
+		response.Averages = append(response.Averages, &analyticsv1.CategoryAverage{
+			CategoryId:   int64(a.CategoryID),
+			CategoryName: a.CategoryName,
+			Average:      a.Average,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetMonthlyCategoryAverages(ctx context.Context, req *analyticsv1.GetMonthlyCategoryAveragesRequest) (*analyticsv1.GetMonthlyCategoryAveragesResponse, error) {
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	averages, err := s.services.TransactionAnalysis.GetAverageSpendByCategory(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	response := &analyticsv1.GetMonthlyCategoryAveragesResponse{}
+	for _, a := range averages {
+		response.Averages = append(response.Averages, &analyticsv1.MonthlyCategoryAverage{
+			CategoryId:   int64(a.CategoryID),
+			CategoryName: a.CategoryName,
+			Month:        timestamppb.New(a.Month),
+			AverageSpend: a.AverageSpend,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetTypeAverages(ctx context.Context, req *analyticsv1.GetTypeAveragesRequest) (*analyticsv1.GetTypeAveragesResponse, error) {
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	averages, err := s.services.Type.GetAverageByType(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	response := &analyticsv1.GetTypeAveragesResponse{}
+	for _, a := range averages {
+		response.Averages = append(response.Averages, &analyticsv1.TypeAverage{
+			Type:    transactionType(a.TypeName),
+			Average: a.Average,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetUserSpending(ctx context.Context, req *analyticsv1.GetUserSpendingRequest) (*analyticsv1.GetUserSpendingResponse, error) {
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	spending, err := s.services.User.GetSpendingByUser(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	response := &analyticsv1.GetUserSpendingResponse{}
+	for _, user := range spending {
+		userSpending := &analyticsv1.UserSpending{
+			UserId:           int64(user.UserID),
+			Expense:          user.Expense,
+			Income:           user.Income,
+			TransactionCount: int64(user.TransactionCount),
+		}
+		for _, c := range user.Categories {
+			userSpending.Categories = append(userSpending.Categories, &analyticsv1.UserCategorySpend{
+				CategoryId:   int64(c.CategoryID),
+				CategoryName: c.CategoryName,
+				Total:        c.Total,
+			})
+		}
+		response.Users = append(response.Users, userSpending)
+	}
+	return response, nil
+}
+
+var queryStages = map[service.QueryStage]analyticsv1.QueryStage{
+	service.QueryStageGeneratingQuery:  analyticsv1.QueryStage_QUERY_STAGE_GENERATING_QUERY,
+	service.QueryStageRunningQuery:     analyticsv1.QueryStage_QUERY_STAGE_RUNNING_QUERY,
+	service.QueryStageAnalyzingResults: analyticsv1.QueryStage_QUERY_STAGE_ANALYZING_RESULTS,
+}
+
+// Query is rate limited and subject to quotas like POST /query, and its LLM
+// usage is recorded under the full method name.
+func (s *Server) Query(req *analyticsv1.QueryRequest, stream grpc.ServerStreamingServer[analyticsv1.QueryResponse]) error {
+	ctx := stream.Context()
+	principal := principalFromContext(ctx)
+
+	if req.Question == "" {
+		return status.Error(codes.InvalidArgument, "question is required")
+	}
+	filter, err := transactionFilter(ctx, req.UserId)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	if allowed, retryAfter := s.queryLimiter.Allow(principal.ClientID(), time.Now()); !allowed {
+		return resourceExhausted(ctx, "rate limit exceeded", retryAfter)
+	}
+
+	reservation, err := s.services.Usage.ReserveQuota(ctx, principal)
+	var quotaErr *service.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return resourceExhausted(ctx, quotaErr.Error(), quotaErr.RetryAfter)
+	}
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	// Usage is charged even when the client cancels the stream.
+	accountingCtx := context.WithoutCancel(ctx)
+	defer s.services.Usage.ReleaseQuota(accountingCtx, reservation)
+
+	_, usage, err := s.services.Query.AnalyzeDatabaseStream(ctx, req.Question, filter, func(event service.QueryEvent) error {
+		if event.Stage != "" {
+			return stream.Send(&analyticsv1.QueryResponse{Event: &analyticsv1.QueryResponse_Stage{Stage: queryStages[event.Stage]}})
+		}
+		return stream.Send(&analyticsv1.QueryResponse{Event: &analyticsv1.QueryResponse_AnswerDelta{AnswerDelta: event.AnswerDelta}})
+	})
+	if recordErr := s.services.Usage.RecordUsage(accountingCtx, principal, analyticsv1.AnalyticsService_Query_FullMethodName, usage); recordErr != nil {
+		slog.ErrorContext(ctx, "Failed to record LLM usage", "component", "grpcapi.Query", "error", recordErr)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		slog.ErrorContext(ctx, "Error analyzing database", "component", "grpcapi.Query", "error", err)
+		return status.Error(codes.FailedPrecondition, "the question could not be answered")
+	}
+	return nil
+}
+
+// transactionFilter scopes the requested user to what the caller may see.
+func transactionFilter(ctx context.Context, requested *int64) (repository.TransactionFilter, error) {
+	var filter repository.TransactionFilter
+	if requested != nil {
+		if *requested <= 0 || *requested > math.MaxInt32 {
+			return filter, status.Errorf(codes.InvalidArgument, "invalid user_id: %d", *requested)
+		}
+		userID := int(*requested)
+		filter.CreatedByID = &userID
+	}
+
+	userID, err := principalFromContext(ctx).ScopeUserID(filter.CreatedByID)
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedByID = userID
+	return filter, nil
+}
+
+// toStatus maps service errors to gRPC statuses. Unexpected errors are logged
+// and hidden behind a generic message.
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Message)
+	case errors.Is(err, domain.ErrOutsideScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+
+	slog.ErrorContext(ctx, "Request failed", "component", "grpcapi", "error", err)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// resourceExhausted reports how long to wait in a retry-after trailer, like
+// the Retry-After header of the REST API.
+func resourceExhausted(ctx context.Context, message string, retryAfter time.Duration) error {
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	grpc.SetTrailer(ctx, metadata.Pairs("retry-after", seconds))
+	return status.Error(codes.ResourceExhausted, message)
+}
+
+func transactionType(t string) analyticsv1.TransactionType {
+	switch domain.Type(t) {
+	case domain.Income:
+		return analyticsv1.TransactionType_TRANSACTION_TYPE_INCOME
+	case domain.Expense:
+		return analyticsv1.TransactionType_TRANSACTION_TYPE_EXPENSE
+	}
+	return analyticsv1.TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func optionalInt64(value *int) *int64 {
+	if value == nil {
+		return nil
+	}
+	v := int64(*value)
+	return &v
+}
+
+func optionalTimestamp(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamppb.New(*value)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	service *service.AuthService
+}
+
+func NewAuthHandler(service *service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+type issueAPIKeyRequest struct {
+	Name              string         `json:"name" binding:"required"`
+	Scopes            []domain.Scope `json:"scopes" binding:"required"`
+	UserID            *int           `json:"user_id"`
+	DailyTokenQuota   *int64         `json:"daily_token_quota"`
+	MonthlyTokenQuota *int64         `json:"monthly_token_quota"`
+	DailyCostQuota    *float64       `json:"daily_cost_quota"`
+	MonthlyCostQuota  *float64       `json:"monthly_cost_quota"`
+}
+
+func (h *AuthHandler) IssueAPIKey(c *gin.Context) {
+	var req issueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota := domain.Quota{
+		DailyTokens:   req.DailyTokenQuota,
+		MonthlyTokens: req.MonthlyTokenQuota,
+		DailyCost:     req.DailyCostQuota,
+		MonthlyCost:   req.MonthlyCostQuota,
+	}
+
+	issued, err := h.service.IssueAPIKey(c.Request.Context(), req.Name, req.Scopes, req.UserID, quota)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, issued)
+}
+
+func (h *AuthHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.service.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	err = h.service.RevokeAPIKey(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) IssueAPIKeyV2(c *gin.Context) {
+	var req issueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	quota := domain.Quota{
+		DailyTokens:   req.DailyTokenQuota,
+		MonthlyTokens: req.MonthlyTokenQuota,
+		DailyCost:     req.DailyCostQuota,
+		MonthlyCost:   req.MonthlyCostQuota,
+	}
+
+	issued, err := h.service.IssueAPIKey(c.Request.Context(), req.Name, req.Scopes, req.UserID, quota)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewIssuedAPIKey(issued))
+}
+
+func (h *AuthHandler) GetAPIKeysV2(c *gin.Context) {
+	keys, err := h.service.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAPIKeys(keys))
+}
+
+func (h *AuthHandler) RevokeAPIKeyV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	err = h.service.RevokeAPIKey(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
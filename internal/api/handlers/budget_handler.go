@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BudgetHandler struct {
+	service *service.BudgetService
+}
+
+func NewBudgetHandler(service *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		service: service,
+	}
+}
+
+// GetBudgetStatusV2 compares every budget with the expenses of the UTC month
+// given as YYYY-MM, the current one by default.
+func (h *BudgetHandler) GetBudgetStatusV2(c *gin.Context) {
+	if !requireHouseholdScope(c) {
+		return
+	}
+
+	month := time.Now()
+	if raw := c.Query("month"); raw != "" {
+		var err error
+		month, err = time.Parse("2006-01", raw)
+		if err != nil {
+			middleware.AbortWithError(c, middleware.BadRequest("month must be formatted as YYYY-MM"))
+			return
+		}
+	}
+
+	statuses, err := h.service.GetStatus(c.Request.Context(), month)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewBudgetStatuses(statuses))
+}
+
+func (h *BudgetHandler) GetBudgetsV2(c *gin.Context) {
+	budgets, err := h.service.GetBudgets(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewBudgets(budgets))
+}
+
+type setBudgetRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// SetBudgetV2 sets the monthly budget of a category, replacing the one it has.
+func (h *BudgetHandler) SetBudgetV2(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid category_id"))
+		return
+	}
+
+	var req setBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	budget, err := h.service.SetBudget(c.Request.Context(), categoryID, req.Amount)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Budget(*budget))
+}
+
+func (h *BudgetHandler) DeleteBudgetV2(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid category_id"))
+		return
+	}
+
+	err = h.service.DeleteBudget(c.Request.Context(), categoryID)
+	if errors.Is(err, repository.ErrBudgetNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
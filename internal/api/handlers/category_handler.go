@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
 	"analytics/internal/repository"
 	"analytics/internal/service"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,7 +35,13 @@ func (h *CategoryHandler) GetCategories(c *gin.Context) {
 }
 
 func (h *CategoryHandler) GetAverageByCategory(c *gin.Context) {
-	average, err := h.service.GetAverageByCategory(c.Request.Context())
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	average, err := h.service.GetAverageByCategory(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,3 +49,76 @@ func (h *CategoryHandler) GetAverageByCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, average)
 }
+
+// GetCategoriesV2 lists every category, or with tree=true only the top level
+// ones, each with its subcategories nested under children.
+func (h *CategoryHandler) GetCategoriesV2(c *gin.Context) {
+	tree, err := strconv.ParseBool(c.DefaultQuery("tree", "false"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("tree must be true or false"))
+		return
+	}
+
+	categories, err := h.repo.GetAllCategories(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+	if tree {
+		c.JSON(http.StatusOK, dto.NewCategoryTree(domain.CategoryTree(categories)))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewCategories(categories))
+}
+
+type setCategoryParentRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// SetCategoryParentV2 moves a category under another, or to the top level
+// when parent_id is null.
+func (h *CategoryHandler) SetCategoryParentV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+	var req setCategoryParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	err = h.service.SetCategoryParent(c.Request.Context(), id, req.ParentID)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) GetAverageByCategoryV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	average, err := h.service.GetAverageByCategory(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAverageCategories(average))
+}
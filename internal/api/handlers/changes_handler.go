@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"analytics/internal/api/middleware"
+	"analytics/internal/changes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const changesHeartbeat = 30 * time.Second
+
+type ChangesHandler struct {
+	listener *changes.Listener
+}
+
+func NewChangesHandler(listener *changes.Listener) *ChangesHandler {
+	return &ChangesHandler{
+		listener: listener,
+	}
+}
+
+// StreamChangesV2 sends a server-sent "change" event naming the table each
+// time transactions or categories change, so clients know when to fetch
+// again. A comment every 30 seconds keeps idle connections open.
+func (h *ChangesHandler) StreamChangesV2(c *gin.Context) {
+	// The stream outlives the server write timeout.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	updates, unsubscribe := h.listener.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(changesHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case table, ok := <-updates:
+			if !ok {
+				return
+			}
+			c.SSEvent("change", gin.H{"table": table})
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
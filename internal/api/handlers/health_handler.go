@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"analytics/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	service *service.HealthService
+}
+
+func NewHealthHandler(service *service.HealthService) *HealthHandler {
+	return &HealthHandler{
+		service: service,
+	}
+}
+
+// Livez reports the process is up and serving. It never checks dependencies,
+// so a database outage does not get the container restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": service.StatusUp})
+}
+
+// Healthcheck is the liveness probe of older clients, which expect its
+// original body.
+func (h *HealthHandler) Healthcheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "OK"})
+}
+
+// Readyz reports whether every dependency needed to serve requests is usable.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	readiness := h.service.GetReadiness(c.Request.Context())
+
+	status := http.StatusOK
+	if readiness.Status != service.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, readiness)
+}
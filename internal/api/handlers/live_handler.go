@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/changes"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// LiveSubprotocol is the WebSocket subprotocol of /api/v2/live. Browsers,
+// which cannot set headers on WebSocket requests, also offer their credential
+// as a "bearer.<credential>" subprotocol.
+const LiveSubprotocol = "analytics.live"
+
+const (
+	liveLatestTransactions = 20
+	// Changes are batched for liveDebounce, so a burst of writes sends one update.
+	liveDebounce       = 500 * time.Millisecond
+	livePingInterval   = 30 * time.Second
+	liveWriteTimeout   = 10 * time.Second
+	liveMaxMessageSize = 4096
+)
+
+// liveTopic is data a client can subscribe to, loaded again whenever one of
+// tables changes.
+type liveTopic struct {
+	tables []changes.Table
+	load   func(ctx context.Context, filter repository.TransactionFilter) (any, error)
+}
+
+type liveRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+type liveMessage struct {
+	Type  string               `json:"type"`
+	Topic string               `json:"topic,omitempty"`
+	Data  json.RawMessage      `json:"data,omitempty"`
+	Error *middleware.APIError `json:"error,omitempty"`
+}
+
+type LiveHandler struct {
+	listener *changes.Listener
+	upgrader websocket.Upgrader
+	topics   map[string]liveTopic
+}
+
+func NewLiveHandler(
+	listener *changes.Listener,
+	transactionRepo *repository.TransactionRepository,
+	totalsService *service.TotalsService,
+	categoryService *service.CategoryService,
+	typeService *service.TypeService,
+	allowedOrigins []string,
+) *LiveHandler {
+	return &LiveHandler{
+		listener: listener,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{LiveSubprotocol},
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin)
+			},
+		},
+		topics: map[string]liveTopic{
+			"month_totals": {
+				tables: []changes.Table{changes.Transactions, changes.Categories},
+				load: func(ctx context.Context, filter repository.TransactionFilter) (any, error) {
+					now := time.Now().UTC()
+					start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+					end := start.AddDate(0, 1, 0)
+					filter.From, filter.To = &start, &end
+
+					byType, err := totalsService.GetTotals(ctx, filter, []service.Dimension{service.DimensionType}, service.PeriodMonth)
+					if err != nil {
+						return nil, err
+					}
+					totals, err := totalsService.GetTotals(ctx, filter, []service.Dimension{service.DimensionCategory, service.DimensionType}, service.PeriodMonth)
+					if err != nil {
+						return nil, err
+					}
+					return dto.NewMonthTotals(start, byType, totals), nil
+				},
+			},
+			"latest_transactions": {
+				tables: []changes.Table{changes.Transactions, changes.Tags, changes.TransactionTags},
+				load: func(ctx context.Context, filter repository.TransactionFilter) (any, error) {
+					transactions, err := transactionRepo.GetLatestTransactions(ctx, filter, liveLatestTransactions, 0)
+					if err != nil {
+						return nil, err
+					}
+					return dto.NewTransactions(transactions), nil
+				},
+			},
+			"categories_average": {
+				tables: []changes.Table{changes.Transactions, changes.Categories},
+				load: func(ctx context.Context, filter repository.TransactionFilter) (any, error) {
+					averages, err := categoryService.GetAverageByCategory(ctx, filter)
+					if err != nil {
+						return nil, err
+					}
+					sort.Slice(averages, func(i, j int) bool {
+						return averages[i].CategoryID < averages[j].CategoryID
+					})
+					return dto.NewAverageCategories(averages), nil
+				},
+			},
+			"types_average": {
+				tables: []changes.Table{changes.Transactions},
+				load: func(ctx context.Context, filter repository.TransactionFilter) (any, error) {
+					averages, err := typeService.GetAverageByType(ctx, filter)
+					if err != nil {
+						return nil, err
+					}
+					sort.Slice(averages, func(i, j int) bool {
+						return averages[i].TypeName < averages[j].TypeName
+					})
+					return dto.NewAverageTypes(averages), nil
+				},
+			},
+		},
+	}
+}
+
+// ServeLiveV2 upgrades to a WebSocket on which the client sends
+// {"type": "subscribe" | "unsubscribe", "topic": ...} and receives
+// {"type": "update", "topic", "data"} with the topic's data on subscribing
+// and whenever it changes, or {"type": "error", "error"} when a request is
+// invalid. The user_id parameter scopes every topic as it does other routes.
+func (h *LiveHandler) ServeLiveV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	// The upgrader answers failed handshakes itself.
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	session := &liveSession{
+		handler:    h,
+		conn:       conn,
+		filter:     filter,
+		subscribed: make(map[string]bool),
+		dirty:      make(map[string]bool),
+		sent:       make(map[string][]byte),
+	}
+	if err := session.run(c.Request.Context()); err != nil {
+		slog.DebugContext(c.Request.Context(), "Live connection closed", "component", "LiveHandler.ServeLiveV2", "error", err)
+	}
+}
+
+type liveSession struct {
+	handler *LiveHandler
+	conn    *websocket.Conn
+	filter  repository.TransactionFilter
+
+	subscribed map[string]bool
+	dirty      map[string]bool
+	// sent is the data last sent per topic, so unchanged data is not resent.
+	sent map[string][]byte
+}
+
+func (s *liveSession) run(ctx context.Context) error {
+	updates, unsubscribe := s.handler.listener.Subscribe()
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	defer close(done)
+	requests, readErr := s.read(done)
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	debouncing := false
+	nextMonth := time.NewTimer(untilNextMonth())
+	defer nextMonth.Stop()
+
+	for {
+		select {
+		case err := <-readErr:
+			return err
+		case req := <-requests:
+			if err := s.handle(ctx, req); err != nil {
+				return err
+			}
+		case table, ok := <-updates:
+			if !ok {
+				return s.closeWith(websocket.CloseGoingAway, "server shutting down")
+			}
+			for name := range s.subscribed {
+				if slices.Contains(s.handler.topics[name].tables, table) {
+					s.dirty[name] = true
+				}
+			}
+			if len(s.dirty) > 0 && !debouncing {
+				debounce.Reset(liveDebounce)
+				debouncing = true
+			}
+		case <-debounce.C:
+			debouncing = false
+			for name := range s.dirty {
+				delete(s.dirty, name)
+				if err := s.push(ctx, name, false); err != nil {
+					return err
+				}
+			}
+		case <-nextMonth.C:
+			nextMonth.Reset(untilNextMonth())
+			if s.subscribed["month_totals"] {
+				if err := s.push(ctx, "month_totals", false); err != nil {
+					return err
+				}
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// read forwards the client's requests until the connection fails or closes,
+// or done is closed. Pongs extend the read deadline, so a client that stops
+// answering pings is dropped.
+func (s *liveSession) read(done <-chan struct{}) (<-chan liveRequest, <-chan error) {
+	requests := make(chan liveRequest)
+	readErr := make(chan error, 1)
+
+	s.conn.SetReadLimit(liveMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(2 * livePingInterval))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(2 * livePingInterval))
+	})
+
+	go func() {
+		for {
+			var req liveRequest
+			err := s.conn.ReadJSON(&req)
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				req = liveRequest{Type: "invalid"}
+			} else if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return requests, readErr
+}
+
+func (s *liveSession) handle(ctx context.Context, req liveRequest) error {
+	switch req.Type {
+	case "subscribe":
+		if _, ok := s.handler.topics[req.Topic]; !ok {
+			return s.fail(req.Topic, middleware.BadRequest(fmt.Sprintf("unknown topic %q, topics are month_totals, latest_transactions, categories_average and types_average", req.Topic)))
+		}
+		s.subscribed[req.Topic] = true
+		return s.push(ctx, req.Topic, true)
+	case "unsubscribe":
+		delete(s.subscribed, req.Topic)
+		delete(s.dirty, req.Topic)
+		delete(s.sent, req.Topic)
+		return nil
+	default:
+		return s.fail(req.Topic, middleware.BadRequest(`messages must be JSON {"type": "subscribe" or "unsubscribe", "topic": ...}`))
+	}
+}
+
+// push sends the topic's current data, unless it is what was last sent and
+// force is false.
+func (s *liveSession) push(ctx context.Context, name string, force bool) error {
+	data, err := s.handler.topics[name].load(ctx, s.filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load live topic", "component", "LiveHandler.ServeLiveV2", "topic", name, "error", err)
+		return s.fail(name, &middleware.APIError{Code: middleware.CodeInternal, Message: "Failed to load topic"})
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if !force && bytes.Equal(encoded, s.sent[name]) {
+		return nil
+	}
+	s.sent[name] = encoded
+	return s.write(liveMessage{Type: "update", Topic: name, Data: encoded})
+}
+
+func (s *liveSession) fail(topic string, apiErr *middleware.APIError) error {
+	return s.write(liveMessage{Type: "error", Topic: topic, Error: apiErr})
+}
+
+func (s *liveSession) write(message liveMessage) error {
+	s.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+	return s.conn.WriteJSON(message)
+}
+
+func (s *liveSession) closeWith(code int, reason string) error {
+	return s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(liveWriteTimeout))
+}
+
+func untilNextMonth() time.Duration {
+	now := time.Now().UTC()
+	return time.Until(time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MerchantHandler struct {
+	service *service.MerchantService
+}
+
+func NewMerchantHandler(service *service.MerchantService) *MerchantHandler {
+	return &MerchantHandler{
+		service: service,
+	}
+}
+
+// GetSpendingByMerchantV2 reports the total, frequency, average ticket and
+// dates of the expenses at each merchant.
+func (h *MerchantHandler) GetSpendingByMerchantV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	spending, err := h.service.GetSpendingByMerchant(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMerchantSpending(spending))
+}
+
+type createMerchantRuleRequest struct {
+	Kind     domain.MerchantRuleKind `json:"kind" binding:"required"`
+	Match    string                  `json:"match" binding:"required"`
+	Merchant string                  `json:"merchant" binding:"required"`
+}
+
+func (h *MerchantHandler) CreateMerchantRuleV2(c *gin.Context) {
+	var req createMerchantRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	rule, err := h.service.CreateRule(c.Request.Context(), req.Kind, req.Match, req.Merchant)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.MerchantRule(*rule))
+}
+
+func (h *MerchantHandler) GetMerchantRulesV2(c *gin.Context) {
+	rules, err := h.service.GetRules(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewMerchantRules(rules))
+}
+
+func (h *MerchantHandler) DeleteMerchantRuleV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	err = h.service.DeleteRule(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrMerchantRuleNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,29 +1,77 @@
 package handlers
 
 import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
 	"analytics/internal/service"
 	"io"
-	"log"
+	"log/slog"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetQueryFromOpenAI(c *gin.Context) {
+type QueryHandler struct {
+	service *service.QueryService
+}
+
+func NewQueryHandler(service *service.QueryService) *QueryHandler {
+	return &QueryHandler{
+		service: service,
+	}
+}
+
+func (h *QueryHandler) GetQueryFromOpenAI(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
+		slog.WarnContext(c.Request.Context(), "Error reading request body", "error", err)
 		c.JSON(400, gin.H{"error": "Failed to read request body"})
 		return
 	}
 	defer c.Request.Body.Close()
 
-	queryService := service.QueryService{}
-	response, err := queryService.AnalyzeDatabase(string(body))
+	response, usage, err := h.service.AnalyzeDatabase(c.Request.Context(), string(body), filter)
+	middleware.SetLLMUsage(c, usage)
 	if err != nil {
-		log.Printf("Error analyzing database: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Error analyzing database", "error", err)
 		c.JSON(400, gin.H{"error": "Error analyzing database"})
 		return
 	}
 
 	c.JSON(200, response)
 }
+
+func (h *QueryHandler) GetQueryFromOpenAIV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("failed to read request body"))
+		return
+	}
+	defer c.Request.Body.Close()
+
+	response, usage, err := h.service.AnalyzeDatabase(c.Request.Context(), string(body), filter)
+	middleware.SetLLMUsage(c, usage)
+	if err != nil {
+		middleware.AbortWithError(c, &middleware.APIError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    middleware.CodeQueryFailed,
+			Message: "the question could not be answered",
+			Cause:   err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.QueryAnswer{Answer: response})
+}
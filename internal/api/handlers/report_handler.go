@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultReportsLimit = 20
+	maxReportsLimit     = 100
+)
+
+type ReportHandler struct {
+	service *service.ReportService
+}
+
+func NewReportHandler(service *service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+	}
+}
+
+// GetReportsV2 lists the latest reports, newest first, optionally of one
+// period, up to limit (default 20, at most 100).
+func (h *ReportHandler) GetReportsV2(c *gin.Context) {
+	if !requireHouseholdScope(c) {
+		return
+	}
+
+	var period *domain.ReportPeriod
+	if raw := c.Query("period"); raw != "" {
+		p := domain.ReportPeriod(raw)
+		if p != domain.ReportWeekly && p != domain.ReportMonthly {
+			middleware.AbortWithError(c, middleware.BadRequest("period must be weekly or monthly"))
+			return
+		}
+		period = &p
+	}
+
+	limit := defaultReportsLimit
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxReportsLimit {
+			middleware.AbortWithError(c, middleware.BadRequest("limit must be between 1 and 100"))
+			return
+		}
+	}
+
+	reports, err := h.service.GetReports(c.Request.Context(), period, limit)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewReports(reports))
+}
+
+func (h *ReportHandler) GetReportV2(c *gin.Context) {
+	if !requireHouseholdScope(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrReportNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewReport(*report))
+}
+
+type generateReportRequest struct {
+	Period domain.ReportPeriod `json:"period" binding:"required"`
+	Date   string              `json:"date"`
+}
+
+// GenerateReportV2 produces and delivers the report of the period containing
+// date, or of the last complete period, replacing the stored one.
+func (h *ReportHandler) GenerateReportV2(c *gin.Context) {
+	var req generateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	var date *time.Time
+	if req.Date != "" {
+		d, err := parseDate(req.Date, time.Time{})
+		if err != nil {
+			middleware.AbortWithError(c, middleware.BadRequest(err.Error()))
+			return
+		}
+		date = &d
+	}
+
+	report, err := h.service.Generate(c.Request.Context(), req.Period, date)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewReport(*report))
+}
+
+// requireHouseholdScope rejects credentials bound to a user, since reports
+// and budgets cover the transactions of every user.
+func requireHouseholdScope(c *gin.Context) bool {
+	if userID, _ := middleware.GetPrincipal(c).ScopeUserID(nil); userID != nil {
+		middleware.AbortWithError(c, middleware.Forbidden("reports and budgets cover every user and need credentials that are not bound to one"))
+		return false
+	}
+	return true
+}
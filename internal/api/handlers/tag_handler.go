@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{
+		service: service,
+	}
+}
+
+func (h *TagHandler) GetTagsV2(c *gin.Context) {
+	tags, err := h.service.GetTags(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewTags(tags))
+}
+
+// GetSpendingByTagV2 reports the income, expenses and dates of the
+// transactions carrying each tag.
+func (h *TagHandler) GetSpendingByTagV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	spending, err := h.service.GetSpendingByTag(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewTagSpending(spending))
+}
+
+type setTransactionTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// SetTransactionTagsV2 replaces the tags of a transaction, creating new tags
+// as needed.
+func (h *TagHandler) SetTransactionTagsV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+	var req setTransactionTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	tags, err := h.service.SetTransactionTags(c.Request.Context(), id, req.Tags)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if errors.Is(err, repository.ErrTransactionNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TransactionTags{TransactionID: id, Tags: tags})
+}
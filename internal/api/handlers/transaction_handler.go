@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
 	"analytics/internal/repository"
 	"analytics/internal/service"
 	"net/http"
@@ -21,7 +23,13 @@ func NewTransactionHandler(repo *repository.TransactionRepository, service *serv
 }
 
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
-	transactions, err := h.repo.GetAllTransactions(c.Request.Context())
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	transactions, err := h.repo.GetTransactions(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -30,7 +38,13 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 }
 
 func (h *TransactionHandler) GetAverageByCategory(c *gin.Context) {
-	average, err := h.service.GetAverageSpendByCategory(c.Request.Context())
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	average, err := h.service.GetAverageSpendByCategory(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,3 +52,18 @@ func (h *TransactionHandler) GetAverageByCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, average)
 }
+
+func (h *TransactionHandler) GetTransactionsV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	transactions, err := h.repo.GetTransactions(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewTransactions(transactions))
+}
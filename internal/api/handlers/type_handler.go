@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
 	"analytics/internal/service"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,12 +21,34 @@ func NewTypeHandler(service *service.TypeService) *TypeHandler {
 }
 
 func (h *TypeHandler) GetAverageByType(c *gin.Context) {
-	average, err := h.service.GetAverageByType(c.Request.Context())
+	filter, err := parseTransactionFilter(c)
 	if err != nil {
-		log.Printf("[TypeHandler.GetAverageByType] ERROR: Failed to get average by type: %v", err)
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	average, err := h.service.GetAverageByType(c.Request.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get average by type", "component", "TypeHandler.GetAverageByType", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, average)
 }
+
+func (h *TypeHandler) GetAverageByTypeV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	average, err := h.service.GetAverageByType(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAverageTypes(average))
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/service"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+type UsageHandler struct {
+	service *service.UsageService
+}
+
+func NewUsageHandler(service *service.UsageService) *UsageHandler {
+	return &UsageHandler{
+		service: service,
+	}
+}
+
+// GetLLMUsage reports LLM spend between the from and to dates (inclusive,
+// YYYY-MM-DD, UTC). It defaults to the last 30 days.
+func (h *UsageHandler) GetLLMUsage(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to, err := parseDate(c.Query("to"), today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := parseDate(c.Query("from"), to.AddDate(0, 0, -29))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	report, err := h.service.GetUsageReport(c.Request.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *UsageHandler) GetLLMUsageV2(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to, err := parseDate(c.Query("to"), today)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest(err.Error()))
+		return
+	}
+	from, err := parseDate(c.Query("from"), to.AddDate(0, 0, -29))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest(err.Error()))
+		return
+	}
+	if from.After(to) {
+		middleware.AbortWithError(c, middleware.BadRequest("from must not be after to"))
+		return
+	}
+
+	report, err := h.service.GetUsageReport(c.Request.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewLLMUsageReport(report))
+}
+
+func parseDate(raw string, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	date, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", raw)
+	}
+	return date, nil
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	service *service.UserService
+}
+
+func NewUserHandler(service *service.UserService) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+func (h *UserHandler) GetSpendingByUser(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	spending, err := h.service.GetSpendingByUser(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, spending)
+}
+
+func (h *UserHandler) GetSpendingByUserV2(c *gin.Context) {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	spending, err := h.service.GetSpendingByUser(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewUserSpending(spending))
+}
+
+// parseTransactionFilter reads the optional user_id query parameter that scopes
+// analytics to the transactions created by a single user, the subtype one, and
+// the tag parameters, repeatable, limiting them to transactions with any of the
+// tags.
+// Credentials bound to a user are always scoped to that user unless they carry
+// the admin scope. Errors are *middleware.APIError: a bad request for invalid
+// parameters, forbidden for a user_id outside the scope of the credentials.
+func parseTransactionFilter(c *gin.Context) (repository.TransactionFilter, error) {
+	var filter repository.TransactionFilter
+
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.Atoi(raw)
+		if err != nil || userID <= 0 {
+			return filter, middleware.BadRequest(fmt.Sprintf("invalid user_id: %q", raw))
+		}
+		filter.CreatedByID = &userID
+	}
+
+	if subtype := c.Query("subtype"); subtype != "" {
+		filter.Subtype = &subtype
+	}
+
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		filter.Tags = make([]string, 0, len(tags))
+		for _, tag := range tags {
+			filter.Tags = append(filter.Tags, domain.NormalizeTagName(tag))
+		}
+	}
+
+	userID, err := middleware.GetPrincipal(c).ScopeUserID(filter.CreatedByID)
+	if errors.Is(err, domain.ErrOutsideScope) {
+		return filter, middleware.Forbidden(err.Error())
+	}
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedByID = userID
+
+	return filter, nil
+}
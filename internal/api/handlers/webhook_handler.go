@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"analytics/internal/api/dto"
+	"analytics/internal/api/middleware"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+	}
+}
+
+type createWebhookRequest struct {
+	URL    string             `json:"url" binding:"required"`
+	Events []domain.EventType `json:"events" binding:"required"`
+}
+
+func (h *WebhookHandler) CreateWebhookV2(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid request body"))
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(c.Request.Context(), req.URL, req.Events)
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		middleware.AbortWithError(c, middleware.BadRequest(validationErr.Message))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewCreatedWebhook(webhook))
+}
+
+func (h *WebhookHandler) GetWebhooksV2(c *gin.Context) {
+	webhooks, err := h.service.GetWebhooks(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewWebhooks(webhooks))
+}
+
+func (h *WebhookHandler) DeleteWebhookV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	err = h.service.DeleteWebhook(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PingWebhookV2 queues a ping event for the webhook and returns its delivery,
+// whose outcome shows up in the delivery log.
+func (h *WebhookHandler) PingWebhookV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	delivery, err := h.service.Ping(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.NewWebhookDelivery(*delivery))
+}
+
+// GetWebhookDeliveriesV2 returns the latest deliveries of a webhook, newest
+// first, up to limit (default 50, at most 500).
+func (h *WebhookHandler) GetWebhookDeliveriesV2(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.BadRequest("invalid id"))
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxDeliveriesLimit {
+			middleware.AbortWithError(c, middleware.BadRequest("limit must be between 1 and 500"))
+			return
+		}
+	}
+
+	deliveries, err := h.service.GetDeliveries(c.Request.Context(), id, limit)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		middleware.AbortWithError(c, middleware.NotFound(err.Error()))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewWebhookDeliveries(deliveries))
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/service"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const principalKey = "principal"
+
+// Auth authenticates requests with either an "Authorization: Bearer" header
+// carrying an API key or JWT, or an X-API-Key header. WebSocket handshakes,
+// whose headers browsers cannot set, may offer it as a "bearer.<credential>"
+// subprotocol instead.
+func Auth(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := c.GetHeader("X-API-Key")
+		if header := c.GetHeader("Authorization"); credential == "" && header != "" {
+			scheme, token, found := strings.Cut(header, " ")
+			if found && strings.EqualFold(scheme, "Bearer") {
+				credential = strings.TrimSpace(token)
+			}
+		}
+		if header := c.GetHeader("Sec-WebSocket-Protocol"); credential == "" && header != "" && websocket.IsWebSocketUpgrade(c.Request) {
+			for _, protocol := range strings.Split(header, ",") {
+				if token, found := strings.CutPrefix(strings.TrimSpace(protocol), "bearer."); found {
+					credential = token
+					break
+				}
+			}
+		}
+
+		principal, err := authService.Authenticate(c.Request.Context(), credential)
+		if errors.Is(err, service.ErrUnauthenticated) {
+			c.Header("WWW-Authenticate", `Bearer realm="analytics"`)
+			AbortWithError(c, &APIError{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: err.Error()})
+			return
+		}
+		if err != nil {
+			AbortWithError(c, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Failed to authenticate request", Cause: err})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose principal was not granted scope.
+func RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil || !principal.HasScope(scope) {
+			AbortWithError(c, &APIError{
+				Status:  http.StatusForbidden,
+				Code:    CodeForbidden,
+				Message: "missing scope " + string(scope),
+				Details: map[string]any{"scope": scope},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns the authenticated principal, or nil when the route is
+// not behind Auth.
+func GetPrincipal(c *gin.Context) *domain.Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*domain.Principal)
+	return principal
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"analytics/internal/metrics"
+	"analytics/internal/responsecache"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cached responses may only be reused by the caller, after checking their
+// ETag is still current.
+const cacheControl = "private, no-cache"
+
+// DataVersioner reports a version of the data responses are computed from,
+// which changes whenever the data does.
+type DataVersioner interface {
+	GetDataVersion(ctx context.Context) (string, error)
+}
+
+// CacheResponses serves GET requests from cache while the data behind them is
+// unchanged. Requests are keyed by path, query parameters and the user the
+// caller is bound to. Successful responses carry a weak ETag of the key and
+// the data version, and clients sending it back in If-None-Match get 304 Not
+// Modified until the data changes.
+func CacheResponses(cache *responsecache.Cache, versions DataVersioner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+
+		version, err := versions.GetDataVersion(c.Request.Context())
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Serving uncached, data version unknown", "component", "middleware.CacheResponses", "error", err)
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c)
+		etag := responseETag(key, version)
+
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Header("ETag", etag)
+			c.Header("Cache-Control", cacheControl)
+			c.AbortWithStatus(http.StatusNotModified)
+			metrics.HTTPResponseCache.WithLabelValues(route, "not_modified").Inc()
+			return
+		}
+
+		now := time.Now()
+		if entry, ok := cache.Get(key, now); ok && entry.ETag == etag {
+			c.Header("ETag", etag)
+			c.Header("Cache-Control", cacheControl)
+			c.Data(http.StatusOK, entry.ContentType, entry.Body)
+			c.Abort()
+			metrics.HTTPResponseCache.WithLabelValues(route, "hit").Inc()
+			return
+		}
+		metrics.HTTPResponseCache.WithLabelValues(route, "miss").Inc()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, etag: etag, limit: cache.MaxBytes()}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		if recorder.Status() == http.StatusOK && !recorder.truncated {
+			cache.Set(key, responsecache.Entry{
+				ETag:        etag,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			}, now)
+		}
+	}
+}
+
+// responseCacheKey identifies what a response depends on besides the data:
+// the endpoint, its query parameters in a canonical order and the user the
+// caller is bound to, which handlers scope the data to.
+func responseCacheKey(c *gin.Context) string {
+	scope := "all"
+	if userID, _ := GetPrincipal(c).ScopeUserID(nil); userID != nil {
+		scope = "user:" + strconv.Itoa(*userID)
+	}
+	return c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "#" + scope
+}
+
+func responseETag(key string, version string) string {
+	sum := sha256.Sum256([]byte(key + "\n" + version))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison If-None-Match calls for.
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder copies the body written to the client, up to limit bytes,
+// and tags successful responses with the ETag.
+type responseRecorder struct {
+	gin.ResponseWriter
+	etag      string
+	limit     int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if code == http.StatusOK {
+		r.Header().Set("ETag", r.etag)
+		r.Header().Set("Cache-Control", cacheControl)
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.Written() {
+		r.WriteHeader(r.Status())
+	}
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	if !r.Written() {
+		r.WriteHeader(r.Status())
+	}
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(data []byte) {
+	if r.truncated {
+		return
+	}
+	if r.body.Len()+len(data) > r.limit {
+		r.truncated = true
+		r.body = bytes.Buffer{}
+		return
+	}
+	r.body.Write(data)
+}
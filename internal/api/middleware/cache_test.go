@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"analytics/internal/responsecache"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`W/"abd"`, false},
+		{`"abd", W/"abc"`, true},
+		{`W/"x",W/"abc" , "y"`, true},
+		{`W/"x", "y"`, false},
+		{"*", true},
+		{`"abc`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, etag, got, tt.want)
+		}
+	}
+}
+
+type fakeDataVersions struct {
+	version string
+	err     error
+}
+
+func (v *fakeDataVersions) GetDataVersion(ctx context.Context) (string, error) {
+	return v.version, v.err
+}
+
+func TestCacheResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	versions := &fakeDataVersions{version: "1"}
+	status := http.StatusOK
+	calls := 0
+	router := gin.New()
+	router.GET("/totals", CacheResponses(responsecache.New(time.Minute, 1<<20), versions), func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"calls": calls})
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/totals?b=2&a=1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || calls != 1 || len(etag) < 4 || etag[:3] != `W/"` {
+		t.Fatalf("first response %d with ETag %q after %d calls", first.Code, etag, calls)
+	}
+
+	if w := get(""); w.Code != http.StatusOK || calls != 1 || w.Body.String() != first.Body.String() || w.Header().Get("ETag") != etag {
+		t.Errorf("repeated request: %d %q with ETag %q after %d calls, want the cached response", w.Code, w.Body.String(), w.Header().Get("ETag"), calls)
+	}
+
+	for _, header := range []string{etag, etag[2:], `"other", ` + etag, "*"} {
+		w := get(header)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag || calls != 1 {
+			t.Errorf("If-None-Match %s: %d with ETag %q after %d calls, want 304", header, w.Code, w.Header().Get("ETag"), calls)
+		}
+	}
+	if w := get(`W/"other"`); w.Code != http.StatusOK {
+		t.Errorf("If-None-Match of another ETag: %d, want 200", w.Code)
+	}
+
+	// New data changes the ETag, so clients holding the old one get it.
+	versions.version = "2"
+	changed := get(etag)
+	if changed.Code != http.StatusOK || calls != 2 || changed.Header().Get("ETag") == etag {
+		t.Errorf("after a data change: %d with ETag %q after %d calls, want a new response", changed.Code, changed.Header().Get("ETag"), calls)
+	}
+
+	// Failures are neither tagged nor cached.
+	versions.version = "3"
+	status = http.StatusInternalServerError
+	for i := 0; i < 2; i++ {
+		if w := get(""); w.Code != status || w.Header().Get("ETag") != "" {
+			t.Errorf("failed response %d with ETag %q", w.Code, w.Header().Get("ETag"))
+		}
+	}
+	if calls != 4 {
+		t.Errorf("%d calls, want failures not to be cached", calls)
+	}
+
+	// Without a data version nothing is cached or matched.
+	versions.err = errors.New("database down")
+	status = http.StatusOK
+	if w := get("*"); w.Code != http.StatusOK || w.Header().Get("ETag") != "" || calls != 5 {
+		t.Errorf("without a data version: %d with ETag %q after %d calls", w.Code, w.Header().Get("ETag"), calls)
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const errorFormatKey = "error_format"
+
+// ErrorFormat is how errors are rendered in responses
+type ErrorFormat int
+
+const (
+	// LegacyErrors renders {"error": message}, as the v1 API always has
+	LegacyErrors ErrorFormat = iota
+	// EnvelopeErrors renders {"error": {"code", "message", "details"}}
+	EnvelopeErrors
+)
+
+// Error codes of the v2 API
+const (
+	CodeBadRequest    = "bad_request"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeNotFound      = "not_found"
+	CodeRateLimited   = "rate_limited"
+	CodeQuotaExceeded = "quota_exceeded"
+	CodeQueryFailed   = "query_failed"
+	CodeInternal      = "internal"
+)
+
+// APIError is an error meant for the client. Cause is only logged, so
+// database and provider messages never reach the response.
+type APIError struct {
+	Status  int            `json:"-"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	Cause   error          `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+func BadRequest(message string) *APIError {
+	return &APIError{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: message}
+}
+
+func Forbidden(message string) *APIError {
+	return &APIError{Status: http.StatusForbidden, Code: CodeForbidden, Message: message}
+}
+
+func NotFound(message string) *APIError {
+	return &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Message: message}
+}
+
+// Internal hides cause behind a generic message.
+func Internal(cause error) *APIError {
+	return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal server error", Cause: cause}
+}
+
+// Errors sets the error format of the routes below it. Errors handlers attach
+// with c.Error without writing a response are rendered once they return, as
+// an internal error unless they are an *APIError.
+func Errors(format ErrorFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorFormatKey, format)
+		c.Next()
+
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+		AbortWithError(c, c.Errors.Last().Err)
+	}
+}
+
+// AbortWithError aborts the request and renders err in the format of the
+// route. Errors that are not an *APIError are rendered as internal errors.
+func AbortWithError(c *gin.Context, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal(err)
+	}
+	if apiErr.Cause != nil && !hasError(c, err) {
+		c.Error(err)
+	}
+
+	format, _ := c.Get(errorFormatKey)
+	if format == EnvelopeErrors {
+		c.AbortWithStatusJSON(apiErr.Status, gin.H{"error": apiErr})
+		return
+	}
+
+	body := gin.H{"error": apiErr.Message}
+	for key, value := range apiErr.Details {
+		body[key] = value
+	}
+	c.AbortWithStatusJSON(apiErr.Status, body)
+}
+
+func hasError(c *gin.Context, err error) bool {
+	for _, e := range c.Errors {
+		if e.Err == err {
+			return true
+		}
+	}
+	return false
+}
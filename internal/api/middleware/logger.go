@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,30 +17,28 @@ func Logger() gin.HandlerFunc {
 
 		statusCode := c.Writer.Status()
 
-		clientIP := c.ClientIP()
+		level := slog.LevelInfo
+		if statusCode >= 500 {
+			level = slog.LevelError
+		} else if statusCode >= 400 {
+			level = slog.LevelWarn
+		}
 
-		method := c.Request.Method
-		path := c.Request.URL.Path
+		attrs := []slog.Attr{
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", statusCode),
+			slog.Duration("duration", duration),
+		}
 		if c.Request.URL.RawQuery != "" {
-			path = path + "?" + c.Request.URL.RawQuery
+			attrs = append(attrs, slog.String("query", c.Request.URL.RawQuery))
 		}
-
-		logMessage := fmt.Sprintf(
-			"[%s] %s %s %d %s %v",
-			clientIP,
-			method,
-			path,
-			statusCode,
-			duration,
-			c.Errors.String(),
-		)
-
-		if statusCode >= 500 {
-			fmt.Printf("ERROR: %s\n", logMessage)
-		} else if statusCode >= 400 {
-			fmt.Printf("WARN: %s\n", logMessage)
-		} else {
-			fmt.Printf("INFO: %s\n", logMessage)
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
 		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
 	}
 }
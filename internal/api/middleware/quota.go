@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"analytics/external"
+	"analytics/internal/service"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const llmUsageKey = "llm_usage"
+
+// Quota rejects requests from clients that have exhausted their LLM quotas,
+// holds part of the quotas while the request runs and records the usage
+// handlers report through SetLLMUsage.
+func Quota(usageService *service.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.Next()
+			return
+		}
+
+		reservation, err := usageService.ReserveQuota(c.Request.Context(), principal)
+		var quotaErr *service.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			abortTooManyRequests(c, CodeQuotaExceeded, quotaErr.Error(), quotaErr.RetryAfter)
+			return
+		}
+		if err != nil {
+			AbortWithError(c, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Failed to check quota", Cause: err})
+			return
+		}
+
+		// The completions are paid for even when the client goes away before
+		// the response, so the usage is charged outside the request context.
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer usageService.ReleaseQuota(ctx, reservation)
+
+		c.Next()
+
+		value, ok := c.Get(llmUsageKey)
+		if !ok {
+			return
+		}
+		usages, _ := value.([]external.Usage)
+		if err := usageService.RecordUsage(ctx, principal, c.FullPath(), usages); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// SetLLMUsage reports the LLM usage of the current request for accounting.
+func SetLLMUsage(c *gin.Context, usages []external.Usage) {
+	c.Set(llmUsageKey, usages)
+}
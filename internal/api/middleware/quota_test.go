@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUsageRepository records usage and holds reservations in memory. Like
+// the database, it fails writes made with a canceled context.
+type fakeUsageRepository struct {
+	mu           sync.Mutex
+	usages       []domain.LLMUsage
+	reservations map[int]repository.UsageTotal
+	nextID       int
+}
+
+func (r *fakeUsageRepository) CreateUsage(ctx context.Context, usage *domain.LLMUsage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage.CreatedAt = time.Now()
+	r.usages = append(r.usages, *usage)
+	return nil
+}
+
+func (r *fakeUsageRepository) ReserveUsage(ctx context.Context, clientID string, since []time.Time, reserved repository.UsageTotal, expiresAt time.Time, check func([]repository.UsageTotal) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var used repository.UsageTotal
+	for _, usage := range r.usages {
+		used.Tokens += usage.PromptTokens + usage.CompletionTokens
+	}
+	for _, held := range r.reservations {
+		used.Tokens += held.Tokens
+	}
+	totals := make([]repository.UsageTotal, len(since))
+	for i := range totals {
+		totals[i] = used
+	}
+	if err := check(totals); err != nil {
+		return 0, err
+	}
+
+	if r.reservations == nil {
+		r.reservations = make(map[int]repository.UsageTotal)
+	}
+	r.nextID++
+	r.reservations[r.nextID] = reserved
+	return r.nextID, nil
+}
+
+func (r *fakeUsageRepository) ReleaseReservation(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.reservations, id)
+	return nil
+}
+
+func (r *fakeUsageRepository) GetAverageUsage(ctx context.Context, clientID string, since time.Time) (repository.UsageTotal, error) {
+	return repository.UsageTotal{Tokens: 10}, nil
+}
+
+func (r *fakeUsageRepository) GetUsageAggregates(ctx context.Context, groupBy string, from time.Time, to time.Time) ([]repository.LLMUsageAggregate, error) {
+	return nil, nil
+}
+
+func quotaRouter(principal *domain.Principal, repo *fakeUsageRepository, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/query", func(c *gin.Context) {
+		c.Set(principalKey, principal)
+	}, Quota(service.NewUsageService(repo)), handler)
+	return router
+}
+
+func TestQuotaChargesUsageAfterTheClientLeaves(t *testing.T) {
+	limit := int64(1000)
+	principal := &domain.Principal{Name: "test", Quota: domain.Quota{DailyTokens: &limit}}
+	repo := &fakeUsageRepository{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router := quotaRouter(principal, repo, func(c *gin.Context) {
+		if len(repo.reservations) != 1 {
+			t.Errorf("%d reservations held while the request runs, want 1", len(repo.reservations))
+		}
+		SetLLMUsage(c, []external.Usage{{Model: "gpt", PromptTokens: 100, CompletionTokens: 20}})
+		// The client goes away once the completions are made.
+		cancel()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/query", nil).WithContext(ctx))
+
+	if len(repo.usages) != 1 || repo.usages[0].PromptTokens != 100 || repo.usages[0].CompletionTokens != 20 || repo.usages[0].ClientID != principal.ClientID() {
+		t.Errorf("recorded %+v, want the completion of the request", repo.usages)
+	}
+	if len(repo.reservations) != 0 {
+		t.Errorf("%d reservations still held", len(repo.reservations))
+	}
+}
+
+func TestQuotaRejectsExhaustedClients(t *testing.T) {
+	limit := int64(100)
+	principal := &domain.Principal{Name: "test", Quota: domain.Quota{DailyTokens: &limit}}
+	repo := &fakeUsageRepository{usages: []domain.LLMUsage{{PromptTokens: 100}}}
+	router := quotaRouter(principal, repo, func(c *gin.Context) {
+		t.Error("handler ran with the quota exhausted")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/query", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 || retryAfter > 24*60*60 {
+		t.Errorf("Retry-After %q, want the seconds until the next UTC day", w.Header().Get("Retry-After"))
+	}
+	if len(repo.reservations) != 0 {
+		t.Errorf("%d reservations held by a rejected request", len(repo.reservations))
+	}
+}
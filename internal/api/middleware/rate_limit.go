@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"analytics/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit takes a token from the client's bucket in limiter. Clients are
+// identified by their credentials when authenticated and by IP otherwise.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		if principal := GetPrincipal(c); principal != nil {
+			client = principal.ClientID()
+		}
+
+		allowed, retryAfter := limiter.Allow(client, time.Now())
+		if !allowed {
+			abortTooManyRequests(c, CodeRateLimited, "rate limit exceeded", retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func abortTooManyRequests(c *gin.Context, code string, message string, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	AbortWithError(c, &APIError{
+		Status:  http.StatusTooManyRequests,
+		Code:    code,
+		Message: message,
+		Details: map[string]any{"retry_after_seconds": seconds},
+	})
+}
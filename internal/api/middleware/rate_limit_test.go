@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"analytics/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitSetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// One token every half second: the wait is rounded up to a second.
+	router.GET("/", RateLimit(ratelimit.New(120, 1)), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{http.StatusNoContent, ""},
+		{http.StatusTooManyRequests, "1"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != tt.wantStatus || w.Header().Get("Retry-After") != tt.wantRetryAfter {
+			t.Errorf("request %d: status %d, Retry-After %q; want %d, %q", i, w.Code, w.Header().Get("Retry-After"), tt.wantStatus, tt.wantRetryAfter)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Analytics API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>`
+
+// Spec serves the OpenAPI document.
+func Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", spec)
+}
+
+// Docs serves a Swagger UI page rendering the OpenAPI document.
+func Docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+// Verify compares the operations in the spec with the routes registered on
+// the router and reports every one that is only in one of them, so the spec
+// cannot silently drift from the API.
+func Verify(routes gin.RoutesInfo) error {
+	var document struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		return fmt.Errorf("invalid openapi.json: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range document.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var problems []string
+	for _, route := range routes {
+		operation := route.Method + " " + pathParam.ReplaceAllString(route.Path, "{$1}")
+		if documented[operation] {
+			delete(documented, operation)
+			continue
+		}
+		problems = append(problems, operation+" is not documented")
+	}
+	for operation := range documented {
+		problems = append(problems, operation+" is documented but not routed")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi.json is out of sync with the routes:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
@@ -1,34 +1,147 @@
 package routes
 
 import (
+	"analytics/internal/api/gql"
 	"analytics/internal/api/handlers"
 	"analytics/internal/api/middleware"
+	"analytics/internal/api/openapi"
+	"analytics/internal/domain"
+	"analytics/internal/ratelimit"
+	"analytics/internal/responsecache"
+	"analytics/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func SetupRoutes(router *gin.Engine, transactionHandler *handlers.TransactionHandler, typeHandler *handlers.TypeHandler, categoryHandler *handlers.CategoryHandler) {
-	router.Use(middleware.Logger())
+// Deps are what the routes are built on
+type Deps struct {
+	QueryLimiter       *ratelimit.Limiter
+	ResponseCache      *responsecache.Cache
+	DataVersions       middleware.DataVersioner
+	AuthService        *service.AuthService
+	UsageService       *service.UsageService
+	TransactionHandler *handlers.TransactionHandler
+	TypeHandler        *handlers.TypeHandler
+	CategoryHandler    *handlers.CategoryHandler
+	UserHandler        *handlers.UserHandler
+	AuthHandler        *handlers.AuthHandler
+	UsageHandler       *handlers.UsageHandler
+	HealthHandler      *handlers.HealthHandler
+	QueryHandler       *handlers.QueryHandler
+	GraphQLHandler     *gql.Handler
+	WebhookHandler     *handlers.WebhookHandler
+	ReportHandler      *handlers.ReportHandler
+	ChangesHandler     *handlers.ChangesHandler
+	LiveHandler        *handlers.LiveHandler
+	TagHandler         *handlers.TagHandler
+	MerchantHandler    *handlers.MerchantHandler
+	BudgetHandler      *handlers.BudgetHandler
+}
+
+func SetupRoutes(router *gin.Engine, deps Deps) {
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.Metrics())
+
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	router.GET("/livez", deps.HealthHandler.Livez)
+	router.GET("/readyz", deps.HealthHandler.Readyz)
+	router.GET("/healthcheck", deps.HealthHandler.Healthcheck)
+
+	router.GET("/openapi.json", openapi.Spec)
+	router.GET("/docs", openapi.Docs)
 
-	router.GET("/healthcheck", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "OK"})
-	})
+	cached := middleware.CacheResponses(deps.ResponseCache, deps.DataVersions)
 
 	v1 := router.Group("/api/v1")
-	v1.POST("/query", handlers.GetQueryFromOpenAI)
+	v1.Use(middleware.Errors(middleware.LegacyErrors), middleware.Auth(deps.AuthService))
+
+	v1.POST("/query",
+		middleware.RequireScope(domain.ScopeRunQuery),
+		middleware.RateLimit(deps.QueryLimiter),
+		middleware.Quota(deps.UsageService),
+		deps.QueryHandler.GetQueryFromOpenAI,
+	)
+
+	analytics := v1.Group("")
+	analytics.Use(middleware.RequireScope(domain.ScopeReadAnalytics))
+
+	{
+		transactions := analytics.Group("/transactions")
+		transactions.GET("/", cached, deps.TransactionHandler.GetTransactions)
+	}
 
 	{
-		transactions := v1.Group("/transactions")
-		transactions.GET("/", transactionHandler.GetTransactions)
+		categories := analytics.Group("/categories")
+		categories.GET("/average", cached, deps.CategoryHandler.GetAverageByCategory)
 	}
 
 	{
-		categories := v1.Group("/categories")
-		categories.GET("/average", categoryHandler.GetAverageByCategory)
+		types := analytics.Group("/types")
+		types.GET("/average", cached, deps.TypeHandler.GetAverageByType)
 	}
 
 	{
-		types := v1.Group("/types")
-		types.GET("/average", typeHandler.GetAverageByType)
+		users := analytics.Group("/users")
+		users.GET("/spending", cached, deps.UserHandler.GetSpendingByUser)
+	}
+
+	{
+		admin := v1.Group("/admin")
+		admin.Use(middleware.RequireScope(domain.ScopeAdmin))
+		admin.POST("/api-keys", deps.AuthHandler.IssueAPIKey)
+		admin.GET("/api-keys", deps.AuthHandler.GetAPIKeys)
+		admin.DELETE("/api-keys/:id", deps.AuthHandler.RevokeAPIKey)
+		admin.GET("/llm-usage", deps.UsageHandler.GetLLMUsage)
+	}
+
+	v2 := router.Group("/api/v2")
+	v2.Use(middleware.Errors(middleware.EnvelopeErrors), middleware.Auth(deps.AuthService))
+
+	v2.POST("/query",
+		middleware.RequireScope(domain.ScopeRunQuery),
+		middleware.RateLimit(deps.QueryLimiter),
+		middleware.Quota(deps.UsageService),
+		deps.QueryHandler.GetQueryFromOpenAIV2,
+	)
+
+	analyticsV2 := v2.Group("")
+	analyticsV2.Use(middleware.RequireScope(domain.ScopeReadAnalytics))
+	analyticsV2.GET("/transactions", cached, deps.TransactionHandler.GetTransactionsV2)
+	analyticsV2.GET("/categories", cached, deps.CategoryHandler.GetCategoriesV2)
+	analyticsV2.GET("/categories/average", cached, deps.CategoryHandler.GetAverageByCategoryV2)
+	analyticsV2.GET("/types/average", cached, deps.TypeHandler.GetAverageByTypeV2)
+	analyticsV2.GET("/users/spending", cached, deps.UserHandler.GetSpendingByUserV2)
+	analyticsV2.GET("/tags", cached, deps.TagHandler.GetTagsV2)
+	analyticsV2.GET("/tags/spending", cached, deps.TagHandler.GetSpendingByTagV2)
+	analyticsV2.GET("/merchants", cached, deps.MerchantHandler.GetSpendingByMerchantV2)
+	analyticsV2.POST("/graphql", deps.GraphQLHandler.Serve)
+	analyticsV2.GET("/reports", deps.ReportHandler.GetReportsV2)
+	analyticsV2.GET("/reports/:id", deps.ReportHandler.GetReportV2)
+	analyticsV2.GET("/budgets", deps.BudgetHandler.GetBudgetStatusV2)
+	analyticsV2.GET("/changes", deps.ChangesHandler.StreamChangesV2)
+	analyticsV2.GET("/live", deps.LiveHandler.ServeLiveV2)
+
+	{
+		admin := v2.Group("/admin")
+		admin.Use(middleware.RequireScope(domain.ScopeAdmin))
+		admin.POST("/api-keys", deps.AuthHandler.IssueAPIKeyV2)
+		admin.GET("/api-keys", deps.AuthHandler.GetAPIKeysV2)
+		admin.DELETE("/api-keys/:id", deps.AuthHandler.RevokeAPIKeyV2)
+		admin.GET("/llm-usage", deps.UsageHandler.GetLLMUsageV2)
+		admin.POST("/webhooks", deps.WebhookHandler.CreateWebhookV2)
+		admin.GET("/webhooks", deps.WebhookHandler.GetWebhooksV2)
+		admin.DELETE("/webhooks/:id", deps.WebhookHandler.DeleteWebhookV2)
+		admin.POST("/webhooks/:id/ping", deps.WebhookHandler.PingWebhookV2)
+		admin.GET("/webhooks/:id/deliveries", deps.WebhookHandler.GetWebhookDeliveriesV2)
+		admin.POST("/reports", deps.ReportHandler.GenerateReportV2)
+		admin.PUT("/categories/:id/parent", deps.CategoryHandler.SetCategoryParentV2)
+		admin.PUT("/transactions/:id/tags", deps.TagHandler.SetTransactionTagsV2)
+		admin.POST("/merchant-rules", deps.MerchantHandler.CreateMerchantRuleV2)
+		admin.GET("/merchant-rules", deps.MerchantHandler.GetMerchantRulesV2)
+		admin.DELETE("/merchant-rules/:id", deps.MerchantHandler.DeleteMerchantRuleV2)
+		admin.GET("/budgets", deps.BudgetHandler.GetBudgetsV2)
+		admin.PUT("/budgets/:category_id", deps.BudgetHandler.SetBudgetV2)
+		admin.DELETE("/budgets/:category_id", deps.BudgetHandler.DeleteBudgetV2)
 	}
 }
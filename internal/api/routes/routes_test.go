@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"analytics/internal/api/openapi"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoutesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router, Deps{})
+
+	if err := openapi.Verify(router.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,48 @@
+package changes
+
+import (
+	"context"
+	"sync"
+)
+
+// Cache holds a value computed from tables, reused until one of them changes.
+// Without a listening Listener it computes the value on every call.
+type Cache[T any] struct {
+	listener *Listener
+	tables   []Table
+
+	mu      sync.Mutex
+	value   T
+	version uint64
+	valid   bool
+}
+
+func NewCache[T any](listener *Listener, tables ...Table) *Cache[T] {
+	return &Cache[T]{listener: listener, tables: tables}
+}
+
+// Get returns the cached value, or the one load computes when a table changed
+// since it was cached. The version is read before loading, so a change made
+// while loading leaves the new value outdated rather than trusted.
+func (c *Cache[T]) Get(ctx context.Context, load func(context.Context) (T, error)) (T, error) {
+	version, listening := c.listener.Version(c.tables...)
+	if listening {
+		c.mu.Lock()
+		if c.valid && c.version == version {
+			value := c.value
+			c.mu.Unlock()
+			return value, nil
+		}
+		c.mu.Unlock()
+	}
+
+	value, err := load(ctx)
+	if err != nil || !listening {
+		return value, err
+	}
+
+	c.mu.Lock()
+	c.value, c.version, c.valid = value, version, true
+	c.mu.Unlock()
+	return value, nil
+}
@@ -0,0 +1,190 @@
+// Package changes follows the writes other services make to the tables
+// analytics reads, through the notifications the database triggers send on
+// Channel, so caches can be trusted until the data behind them changes.
+package changes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Channel is the notification channel the change triggers send on, with the
+// name of the table written to as payload.
+const Channel = "analytics_changes"
+
+type Table string
+
+const (
+	Transactions    Table = "transactions"
+	Categories      Table = "categories"
+	Tags            Table = "tags"
+	TransactionTags Table = "transaction_tags"
+	MerchantRules   Table = "merchant_rules"
+)
+
+var Tables = []Table{Transactions, Categories, Tags, TransactionTags, MerchantRules}
+
+const (
+	subscriptionBuffer = 32
+	minRetryDelay      = time.Second
+	maxRetryDelay      = 30 * time.Second
+)
+
+// Listener holds a dedicated connection listening on Channel. It keeps a
+// version per table, bumped on every change, and forwards changes to its
+// subscribers. While it is not connected changes go unseen, so it reports
+// versions as unknown and every cache relying on them is bypassed.
+type Listener struct {
+	config *pgx.ConnConfig
+
+	mu          sync.Mutex
+	listening   bool
+	versions    map[Table]uint64
+	subscribers map[chan Table]struct{}
+	closed      bool
+}
+
+func NewListener(config *pgx.ConnConfig) *Listener {
+	return &Listener{
+		config:      config,
+		versions:    make(map[Table]uint64),
+		subscribers: make(map[chan Table]struct{}),
+	}
+}
+
+// Run listens until ctx is done, reconnecting with backoff when the
+// connection is lost. Every table counts as changed on each (re)connection,
+// since changes made while disconnected were missed.
+func (l *Listener) Run(ctx context.Context) {
+	delay := minRetryDelay
+	for {
+		err := l.listen(ctx, func() { delay = minRetryDelay })
+		l.setListening(false)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Lost change notifications, reconnecting", "component", "changes.Listener", "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, connected func()) error {
+	conn, err := pgx.ConnectConfig(ctx, l.config)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	connected()
+	l.setListening(true)
+	slog.Info("Listening for changes", "component", "changes.Listener", "channel", Channel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Debug("Change notified", "component", "changes.Listener", "table", notification.Payload)
+		l.changed(Table(notification.Payload))
+	}
+}
+
+// setListening records whether changes are being received. Both ways every
+// table counts as changed: going down, caches must stop being trusted, and
+// coming up, changes made in between are unknown.
+func (l *Listener) setListening(listening bool) {
+	l.mu.Lock()
+	wasListening := l.listening
+	l.listening = listening
+	l.mu.Unlock()
+
+	if listening != wasListening {
+		for _, table := range Tables {
+			l.changed(table)
+		}
+	}
+}
+
+func (l *Listener) changed(table Table) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.versions[table]++
+	for subscriber := range l.subscribers {
+		select {
+		case subscriber <- table:
+		default:
+			// The subscriber is this far behind; it still has changes to handle.
+		}
+	}
+}
+
+// Version returns a number that grows whenever one of tables changes, and
+// false when changes are not being received. A nil Listener never receives
+// any.
+func (l *Listener) Version(tables ...Table) (uint64, bool) {
+	if l == nil {
+		return 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var version uint64
+	for _, table := range tables {
+		version += l.versions[table]
+	}
+	return version, l.listening
+}
+
+// Subscribe returns a channel receiving the tables that change, until
+// unsubscribe is called or the Listener is closed. A subscriber that falls
+// more than a few changes behind misses the next ones.
+func (l *Listener) Subscribe() (<-chan Table, func()) {
+	subscriber := make(chan Table, subscriptionBuffer)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+	l.subscribers[subscriber] = struct{}{}
+
+	return subscriber, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subscribers[subscriber]; ok {
+			delete(l.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// Close ends every subscription, so that streams to clients finish and the
+// server can shut down.
+func (l *Listener) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	for subscriber := range l.subscribers {
+		delete(l.subscribers, subscriber)
+		close(subscriber)
+	}
+}
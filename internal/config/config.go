@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const DefaultEnvFile = "stack.env"
+
+type HTTPConfig struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	TrustedProxies    []string
+	CORSAllowOrigins  []string
+	GinMode           string
+}
+
+type GRPCConfig struct {
+	Addr string
+}
+
+type DatabaseConfig struct {
+	URL               string
+	MaxConns          int
+	MinConns          int
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+	StatementTimeout  time.Duration
+	AutoMigrate       bool
+}
+
+type OpenAIConfig struct {
+	APIKey string
+}
+
+type AuthConfig struct {
+	JWTSecret   string
+	AdminAPIKey string
+}
+
+type QueryConfig struct {
+	DatabaseURL        string
+	RateLimitPerMinute int
+	RateLimitBurst     int
+}
+
+type ResponseCacheConfig struct {
+	TTL      time.Duration
+	MaxBytes int
+}
+
+type WebhookConfig struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+}
+
+type AlertConfig struct {
+	CheckInterval time.Duration
+}
+
+type ReportConfig struct {
+	Periods       []string
+	CheckInterval time.Duration
+	Narrative     bool
+	EmailTo       []string
+}
+
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+type LogConfig struct {
+	Level         string
+	SensitiveData bool
+}
+
+type TracingConfig struct {
+	Exporter string
+	Endpoint string
+}
+
+type HealthConfig struct {
+	CheckLLM bool
+	Timeout  time.Duration
+}
+
+type Config struct {
+	EnvFile string
+	// Command is what follows the flags on the command line, such as
+	// "migrate up". It is empty when serving.
+	Command       []string
+	HTTP          HTTPConfig
+	GRPC          GRPCConfig
+	Database      DatabaseConfig
+	OpenAI        OpenAIConfig
+	Auth          AuthConfig
+	Query         QueryConfig
+	ResponseCache ResponseCacheConfig
+	Webhook       WebhookConfig
+	Alert         AlertConfig
+	Report        ReportConfig
+	SMTP          SMTPConfig
+	Log           LogConfig
+	Tracing       TracingConfig
+	Health        HealthConfig
+}
+
+// Error lists every invalid setting found while loading the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// defaults, the env file (stack.env unless -env-file or ENV_FILE say
+// otherwise), the process environment and command line flags. Every problem
+// found is reported at once in an *Error.
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	l := newLoader(args)
+
+	l.string(&cfg.HTTP.Addr, "http-addr", "HTTP_ADDR", "0.0.0.0:1234", "address to listen on")
+	l.duration(&cfg.HTTP.ReadHeaderTimeout, "http-read-header-timeout", "HTTP_READ_HEADER_TIMEOUT", 5*time.Second, "time allowed to read request headers")
+	l.duration(&cfg.HTTP.ReadTimeout, "http-read-timeout", "HTTP_READ_TIMEOUT", 15*time.Second, "time allowed to read a request")
+	l.duration(&cfg.HTTP.WriteTimeout, "http-write-timeout", "HTTP_WRITE_TIMEOUT", 2*time.Minute, "time allowed to write a response")
+	l.duration(&cfg.HTTP.IdleTimeout, "http-idle-timeout", "HTTP_IDLE_TIMEOUT", time.Minute, "keep-alive idle timeout")
+	l.duration(&cfg.HTTP.ShutdownTimeout, "shutdown-timeout", "SHUTDOWN_TIMEOUT", 30*time.Second, "time allowed to drain requests on shutdown")
+	l.list(&cfg.HTTP.TrustedProxies, "trusted-proxies", "TRUSTED_PROXIES", []string{"172.16.0.0/12", "192.168.0.0/16"}, "comma separated trusted proxy CIDRs")
+	l.list(&cfg.HTTP.CORSAllowOrigins, "cors-allow-origins", "CORS_ALLOW_ORIGINS", []string{"*"}, "comma separated allowed CORS origins")
+	l.string(&cfg.HTTP.GinMode, "gin-mode", "GIN_MODE", "release", "gin mode: debug, release or test")
+
+	l.string(&cfg.GRPC.Addr, "grpc-addr", "GRPC_ADDR", "127.0.0.1:9090", "address to serve gRPC on, empty disables it")
+
+	l.string(&cfg.Database.URL, "database-url", "DATABASE_URL", "", "Postgres connection URL")
+	l.int(&cfg.Database.MaxConns, "db-max-conns", "DB_MAX_CONNS", 10, "maximum connections in the pool")
+	l.int(&cfg.Database.MinConns, "db-min-conns", "DB_MIN_CONNS", 1, "connections kept open when idle")
+	l.duration(&cfg.Database.MaxConnLifetime, "db-max-conn-lifetime", "DB_MAX_CONN_LIFETIME", time.Hour, "maximum age of a connection")
+	l.duration(&cfg.Database.MaxConnIdleTime, "db-max-conn-idle-time", "DB_MAX_CONN_IDLE_TIME", 30*time.Minute, "idle time after which a connection is closed")
+	l.duration(&cfg.Database.HealthCheckPeriod, "db-health-check-period", "DB_HEALTH_CHECK_PERIOD", time.Minute, "interval between idle connection health checks")
+	l.duration(&cfg.Database.ConnectTimeout, "db-connect-timeout", "DB_CONNECT_TIMEOUT", 5*time.Second, "timeout to establish a connection")
+	l.duration(&cfg.Database.StatementTimeout, "db-statement-timeout", "DB_STATEMENT_TIMEOUT", 30*time.Second, "server side timeout of each statement")
+	l.bool(&cfg.Database.AutoMigrate, "db-auto-migrate", "DB_AUTO_MIGRATE", false, "apply pending migrations on startup instead of refusing to start")
+
+	l.string(&cfg.OpenAI.APIKey, "openai-api-key", "OPENAI_API_KEY", "", "OpenAI API key")
+
+	l.string(&cfg.Auth.JWTSecret, "jwt-secret", "JWT_SECRET", "", "HS256 secret for bearer tokens, empty disables JWTs")
+	l.string(&cfg.Auth.AdminAPIKey, "admin-api-key", "ADMIN_API_KEY", "", "bootstrap admin API key")
+
+	l.string(&cfg.Query.DatabaseURL, "query-database-url", "QUERY_DATABASE_URL", "", "Postgres connection URL of the analytics_query role natural language queries run as")
+	l.int(&cfg.Query.RateLimitPerMinute, "query-rate-limit", "QUERY_RATE_LIMIT_PER_MINUTE", 6, "/query requests per minute per client")
+	l.int(&cfg.Query.RateLimitBurst, "query-rate-burst", "QUERY_RATE_LIMIT_BURST", 3, "/query burst size per client")
+
+	l.duration(&cfg.ResponseCache.TTL, "response-cache-ttl", "RESPONSE_CACHE_TTL", 5*time.Minute, "time analytics responses are cached for")
+	l.int(&cfg.ResponseCache.MaxBytes, "response-cache-max-bytes", "RESPONSE_CACHE_MAX_BYTES", 32<<20, "total size of cached analytics responses, 0 disables the cache")
+
+	l.duration(&cfg.Webhook.Timeout, "webhook-timeout", "WEBHOOK_TIMEOUT", 10*time.Second, "time allowed for a webhook receiver to answer")
+	l.int(&cfg.Webhook.MaxAttempts, "webhook-max-attempts", "WEBHOOK_MAX_ATTEMPTS", 8, "attempts before a webhook delivery is given up")
+	l.duration(&cfg.Webhook.PollInterval, "webhook-poll-interval", "WEBHOOK_POLL_INTERVAL", 5*time.Second, "interval between checks for due webhook retries")
+
+	l.duration(&cfg.Alert.CheckInterval, "alert-check-interval", "ALERT_CHECK_INTERVAL", time.Minute, "interval between checks for exceeded budgets and anomalous expenses")
+
+	l.list(&cfg.Report.Periods, "report-periods", "REPORT_PERIODS", []string{"weekly", "monthly"}, "comma separated report periods to produce (weekly, monthly), empty disables the scheduler")
+	l.duration(&cfg.Report.CheckInterval, "report-check-interval", "REPORT_CHECK_INTERVAL", 15*time.Minute, "interval between checks for reports that are due")
+	l.bool(&cfg.Report.Narrative, "report-narrative", "REPORT_NARRATIVE", false, "have the LLM write a narrative for each report")
+	l.list(&cfg.Report.EmailTo, "report-email-to", "REPORT_EMAIL_TO", nil, "comma separated addresses to email reports to")
+
+	l.string(&cfg.SMTP.Addr, "smtp-addr", "SMTP_ADDR", "", "SMTP server host:port")
+	l.string(&cfg.SMTP.Username, "smtp-username", "SMTP_USERNAME", "", "SMTP username, empty skips authentication")
+	l.string(&cfg.SMTP.Password, "smtp-password", "SMTP_PASSWORD", "", "SMTP password")
+	l.string(&cfg.SMTP.From, "smtp-from", "SMTP_FROM", "", "sender address of emails")
+	l.duration(&cfg.SMTP.Timeout, "smtp-timeout", "SMTP_TIMEOUT", 30*time.Second, "time allowed to send an email")
+
+	l.string(&cfg.Log.Level, "log-level", "LOG_LEVEL", "info", "log level: debug, info, warn or error")
+	l.bool(&cfg.Log.SensitiveData, "log-sensitive-data", "LOG_SENSITIVE_DATA", false, "include query results and prompts in debug logs")
+
+	l.string(&cfg.Tracing.Exporter, "traces-exporter", "TRACES_EXPORTER", "none", "trace exporter: none, stdout or otlp")
+	l.string(&cfg.Tracing.Endpoint, "traces-endpoint", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "", "OTLP/HTTP traces endpoint URL")
+
+	l.bool(&cfg.Health.CheckLLM, "readiness-check-llm", "READINESS_CHECK_LLM", false, "check the LLM provider is reachable in /readyz")
+	l.duration(&cfg.Health.Timeout, "readiness-timeout", "READINESS_TIMEOUT", 2*time.Second, "timeout of each /readyz dependency check")
+
+	cfg.EnvFile = l.envFile
+	if err := l.parseFlags(); err != nil {
+		return nil, err
+	}
+	cfg.Command = l.flags.Args()
+
+	cfg.validate(l)
+
+	if len(l.problems) > 0 {
+		return nil, &Error{Problems: l.problems}
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate(l *loader) {
+	if c.Database.URL == "" {
+		l.problem("DATABASE_URL is required")
+	} else if _, err := pgxpool.ParseConfig(c.Database.URL); err != nil {
+		l.problem(fmt.Sprintf("DATABASE_URL is invalid: %v", err))
+	}
+	if c.OpenAI.APIKey == "" && len(c.Command) == 0 {
+		l.problem("OPENAI_API_KEY is required")
+	}
+	if c.Query.DatabaseURL == "" {
+		if len(c.Command) == 0 {
+			l.problem("QUERY_DATABASE_URL is required")
+		}
+	} else if _, err := pgxpool.ParseConfig(c.Query.DatabaseURL); err != nil {
+		l.problem(fmt.Sprintf("QUERY_DATABASE_URL is invalid: %v", err))
+	}
+	if len(c.Command) > 0 && c.Command[0] != "migrate" {
+		l.problem(fmt.Sprintf("unknown command %q, the only command is migrate", c.Command[0]))
+	}
+
+	switch c.HTTP.GinMode {
+	case "debug", "release", "test":
+	default:
+		l.problem(fmt.Sprintf("GIN_MODE must be debug, release or test, got %q", c.HTTP.GinMode))
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		l.problem(fmt.Sprintf("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		l.problem(fmt.Sprintf("TRACES_EXPORTER must be none, stdout or otlp, got %q", c.Tracing.Exporter))
+	}
+
+	if c.Database.MaxConns <= 0 {
+		l.problem("DB_MAX_CONNS must be positive")
+	}
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		l.problem("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+
+	if c.Query.RateLimitPerMinute <= 0 {
+		l.problem("QUERY_RATE_LIMIT_PER_MINUTE must be positive")
+	}
+	if c.Query.RateLimitBurst <= 0 {
+		l.problem("QUERY_RATE_LIMIT_BURST must be positive")
+	}
+
+	if c.ResponseCache.TTL < 0 {
+		l.problem("RESPONSE_CACHE_TTL must not be negative")
+	}
+	if c.ResponseCache.MaxBytes < 0 {
+		l.problem("RESPONSE_CACHE_MAX_BYTES must not be negative")
+	}
+
+	for _, period := range c.Report.Periods {
+		switch period {
+		case "weekly", "monthly":
+		default:
+			l.problem(fmt.Sprintf("REPORT_PERIODS must only contain weekly and monthly, got %q", period))
+		}
+	}
+	if len(c.Report.EmailTo) > 0 && (c.SMTP.Addr == "" || c.SMTP.From == "") {
+		l.problem("SMTP_ADDR and SMTP_FROM are required to email reports")
+	}
+
+	if c.Webhook.MaxAttempts <= 0 {
+		l.problem("WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.HTTP.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+		{"READINESS_TIMEOUT", c.Health.Timeout},
+		{"WEBHOOK_TIMEOUT", c.Webhook.Timeout},
+		{"WEBHOOK_POLL_INTERVAL", c.Webhook.PollInterval},
+		{"ALERT_CHECK_INTERVAL", c.Alert.CheckInterval},
+		{"REPORT_CHECK_INTERVAL", c.Report.CheckInterval},
+		{"SMTP_TIMEOUT", c.SMTP.Timeout},
+		{"DB_MAX_CONN_LIFETIME", c.Database.MaxConnLifetime},
+		{"DB_MAX_CONN_IDLE_TIME", c.Database.MaxConnIdleTime},
+		{"DB_HEALTH_CHECK_PERIOD", c.Database.HealthCheckPeriod},
+		{"DB_CONNECT_TIMEOUT", c.Database.ConnectTimeout},
+		{"DB_STATEMENT_TIMEOUT", c.Database.StatementTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			l.problem(timeout.name + " must be a positive duration")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets the settings read from the process environment for the
+// duration of the test.
+func clearEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func writeEnvFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var requiredSettings = []string{
+	"DATABASE_URL=postgres://analytics@localhost/analytics",
+	"QUERY_DATABASE_URL=postgres://analytics_query@localhost/analytics",
+	"OPENAI_API_KEY=sk-test",
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		flag    string
+		want    int
+		wantLog string
+	}{
+		{name: "default", want: 6, wantLog: "info"},
+		{name: "env file over default", file: "7", want: 7, wantLog: "warn"},
+		{name: "environment over env file", file: "7", env: "8", want: 8, wantLog: "error"},
+		{name: "flag over environment", file: "7", env: "8", flag: "9", want: 9, wantLog: "debug"},
+		{name: "flag over default", flag: "9", want: 9, wantLog: "debug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "ENV_FILE", "DATABASE_URL", "QUERY_DATABASE_URL", "OPENAI_API_KEY", "QUERY_RATE_LIMIT_PER_MINUTE", "LOG_LEVEL")
+
+			lines := append([]string(nil), requiredSettings...)
+			if tt.file != "" {
+				lines = append(lines, "QUERY_RATE_LIMIT_PER_MINUTE="+tt.file, "LOG_LEVEL=warn")
+			}
+			args := []string{"-env-file", writeEnvFile(t, lines...)}
+			if tt.env != "" {
+				t.Setenv("QUERY_RATE_LIMIT_PER_MINUTE", tt.env)
+				t.Setenv("LOG_LEVEL", "error")
+			}
+			if tt.flag != "" {
+				args = append(args, "-query-rate-limit="+tt.flag, "-log-level", "debug")
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Query.RateLimitPerMinute != tt.want || cfg.Log.Level != tt.wantLog {
+				t.Errorf("got rate limit %d and log level %q, want %d and %q", cfg.Query.RateLimitPerMinute, cfg.Log.Level, tt.want, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	clearEnv(t, "ENV_FILE", "DATABASE_URL", "QUERY_DATABASE_URL", "OPENAI_API_KEY", "HTTP_ADDR")
+
+	path := writeEnvFile(t, append(requiredSettings, "HTTP_ADDR=127.0.0.1:8080")...)
+	t.Setenv("ENV_FILE", path)
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load with ENV_FILE: %v", err)
+	}
+	if cfg.EnvFile != path || cfg.HTTP.Addr != "127.0.0.1:8080" {
+		t.Errorf("got env file %q and address %q from ENV_FILE", cfg.EnvFile, cfg.HTTP.Addr)
+	}
+
+	// -env-file wins over ENV_FILE, and must exist when given.
+	missing := filepath.Join(t.TempDir(), "missing.env")
+	_, err = Load([]string{"-env-file=" + missing})
+	var configErr *Error
+	if !errors.As(err, &configErr) || !strings.Contains(configErr.Error(), missing) {
+		t.Errorf("Load with a missing -env-file: %v", err)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	clearEnv(t, "ENV_FILE", "DATABASE_URL", "QUERY_DATABASE_URL", "OPENAI_API_KEY", "DB_MAX_CONNS", "LOG_LEVEL", "HTTP_READ_TIMEOUT", "REPORT_PERIODS")
+
+	path := writeEnvFile(t, "OPENAI_API_KEY=sk-test", "DB_MAX_CONNS=0", "REPORT_PERIODS=weekly,daily")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("HTTP_READ_TIMEOUT", "soon")
+
+	_, err := Load([]string{"-env-file", path, "-gin-mode=prod", "-webhook-max-attempts=x"})
+
+	var configErr *Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Load error = %v, want an *Error", err)
+	}
+	want := []string{
+		"HTTP_READ_TIMEOUT: \"soon\" is not a duration",
+		"-webhook-max-attempts",
+		"DATABASE_URL is required",
+		"QUERY_DATABASE_URL is required",
+		"GIN_MODE must be debug, release or test, got \"prod\"",
+		"LOG_LEVEL must be debug, info, warn or error, got \"loud\"",
+		"DB_MAX_CONNS must be positive",
+		"DB_MIN_CONNS must be between 0 and DB_MAX_CONNS",
+		"REPORT_PERIODS must only contain weekly and monthly, got \"daily\"",
+	}
+	if len(configErr.Problems) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%s", len(configErr.Problems), len(want), configErr)
+	}
+	for _, problem := range want {
+		if !strings.Contains(configErr.Error(), problem) {
+			t.Errorf("missing problem %q in:\n%s", problem, configErr)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// loader resolves each setting from its default, the env file, the process
+// environment and the command line, collecting problems instead of failing on
+// the first one.
+type loader struct {
+	args     []string
+	flags    *flag.FlagSet
+	envFile  string
+	fileEnv  map[string]string
+	problems []string
+}
+
+func newLoader(args []string) *loader {
+	l := &loader{
+		args:  args,
+		flags: flag.NewFlagSet("analytics", flag.ContinueOnError),
+	}
+	l.flags.SetOutput(io.Discard)
+
+	envFile, explicit := findEnvFile(args)
+	l.flags.String("env-file", DefaultEnvFile, "env file to load settings from (env ENV_FILE)")
+	l.envFile = envFile
+
+	fileEnv, err := godotenv.Read(envFile)
+	switch {
+	case err == nil:
+		l.fileEnv = fileEnv
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		l.fileEnv = map[string]string{}
+	default:
+		l.problem(fmt.Sprintf("env file %s: %v", envFile, err))
+		l.fileEnv = map[string]string{}
+	}
+
+	return l
+}
+
+// findEnvFile picks the env file before flags are parsed, since it provides
+// values for the other flags' defaults.
+func findEnvFile(args []string) (string, bool) {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "env-file" {
+			continue
+		}
+		if hasValue {
+			return value, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+
+	if envFile, ok := os.LookupEnv("ENV_FILE"); ok && envFile != "" {
+		return envFile, true
+	}
+	return DefaultEnvFile, false
+}
+
+func (l *loader) problem(problem string) {
+	l.problems = append(l.problems, problem)
+}
+
+func (l *loader) lookup(env string) (string, bool) {
+	if value, ok := os.LookupEnv(env); ok {
+		return value, true
+	}
+	value, ok := l.fileEnv[env]
+	return value, ok
+}
+
+// setting registers a flag and applies the environment value, if any, with parse.
+func (l *loader) setting(name string, env string, usage string, parse func(string) error) {
+	if value, ok := l.lookup(env); ok {
+		if err := parse(value); err != nil {
+			l.problem(fmt.Sprintf("%s: %v", env, err))
+		}
+	}
+
+	l.flags.Func(name, fmt.Sprintf("%s (env %s)", usage, env), parse)
+}
+
+func (l *loader) string(target *string, name string, env string, fallback string, usage string) {
+	*target = fallback
+	l.setting(name, env, usage, func(value string) error {
+		*target = value
+		return nil
+	})
+}
+
+func (l *loader) list(target *[]string, name string, env string, fallback []string, usage string) {
+	*target = fallback
+	l.setting(name, env, usage, func(value string) error {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*target = items
+		return nil
+	})
+}
+
+func (l *loader) int(target *int, name string, env string, fallback int, usage string) {
+	*target = fallback
+	l.setting(name, env, usage, func(value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*target = parsed
+		return nil
+	})
+}
+
+func (l *loader) bool(target *bool, name string, env string, fallback bool, usage string) {
+	*target = fallback
+	l.setting(name, env, usage, func(value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*target = parsed
+		return nil
+	})
+}
+
+func (l *loader) duration(target *time.Duration, name string, env string, fallback time.Duration, usage string) {
+	*target = fallback
+	l.setting(name, env, usage, func(value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*target = parsed
+		return nil
+	})
+}
+
+// parseFlags applies the command line. -h prints the usage of every setting.
+func (l *loader) parseFlags() error {
+	err := l.flags.Parse(l.args)
+	if errors.Is(err, flag.ErrHelp) {
+		l.flags.SetOutput(os.Stderr)
+		l.flags.PrintDefaults()
+		return err
+	}
+	if err != nil {
+		l.problem(err.Error())
+	}
+	return nil
+}
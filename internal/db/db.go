@@ -1,34 +1,60 @@
 package db
 
 import (
+	"analytics/internal/config"
+	"analytics/internal/tracing"
 	"context"
-	"log"
-	"os"
+	"fmt"
+	"strconv"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DatabaseService owns the connection pool shared by every repository and
+// service. It is created once at startup and closed on shutdown.
 type DatabaseService struct {
-	pool *pgxpool.Pool
+	config config.DatabaseConfig
+	pool   *pgxpool.Pool
 }
 
-func (db *DatabaseService) GetPool() *pgxpool.Pool {
+func NewDatabaseService(config config.DatabaseConfig) *DatabaseService {
+	return &DatabaseService{config: config}
+}
+
+// GetPool returns the pool, creating it and checking a connection can be made
+// on first use.
+func (db *DatabaseService) GetPool(ctx context.Context) (*pgxpool.Pool, error) {
 	if db.pool != nil {
-		return db.pool
+		return db.pool, nil
 	}
 
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatalf("DATABASE_URL is not set")
+	poolConfig, err := pgxpool.ParseConfig(db.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database url: %w", err)
 	}
+	poolConfig.MaxConns = int32(db.config.MaxConns)
+	poolConfig.MinConns = int32(db.config.MinConns)
+	poolConfig.MaxConnLifetime = db.config.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = db.config.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = db.config.HealthCheckPeriod
+	poolConfig.ConnConfig.ConnectTimeout = db.config.ConnectTimeout
+	poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(db.config.StatementTimeout.Milliseconds(), 10)
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
-	pool, err := pgxpool.New(context.Background(), dbURL)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		return nil, fmt.Errorf("unable to create pool: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, db.config.ConnectTimeout)
+	defer cancel()
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
 	db.pool = pool
-	return pool
+	return pool, nil
 }
 
 func (db *DatabaseService) Close() {
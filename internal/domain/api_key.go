@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scope is a permission granted to an API key or token
+type Scope string
+
+const (
+	ScopeReadAnalytics Scope = "analytics:read"
+	ScopeRunQuery      Scope = "query:run"
+	ScopeAdmin         Scope = "admin"
+)
+
+type APIKey struct {
+	ID         int     `db:"id"`
+	Name       string  `db:"name"`
+	Prefix     string  `db:"prefix"`
+	KeyHash    string  `db:"key_hash" json:"-"`
+	Scopes     []Scope `db:"scopes"`
+	UserID     *int    `db:"user_id"`
+	Quota      Quota
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// Quota caps the LLM spend of an API key. Nil limits are not enforced.
+type Quota struct {
+	DailyTokens   *int64   `db:"daily_token_quota"`
+	MonthlyTokens *int64   `db:"monthly_token_quota"`
+	DailyCost     *float64 `db:"daily_cost_quota"`
+	MonthlyCost   *float64 `db:"monthly_cost_quota"`
+}
+
+// ErrOutsideScope is returned when credentials bound to a user ask for another user's data
+var ErrOutsideScope = errors.New("outside the scope of these credentials")
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	KeyID  *int
+	Name   string
+	UserID *int
+	Scopes []Scope
+	Quota  Quota
+}
+
+// ClientID identifies the caller for rate limiting and usage accounting
+func (p *Principal) ClientID() string {
+	if p.KeyID != nil {
+		return fmt.Sprintf("key:%d", *p.KeyID)
+	}
+	return "token:" + p.Name
+}
+
+// HasScope reports whether the principal was granted scope. Admin implies every scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeUserID returns the user whose transactions the principal may see given
+// the requested one, which may be nil for all users. Principals bound to a user
+// are always scoped to it unless they carry the admin scope.
+func (p *Principal) ScopeUserID(requested *int) (*int, error) {
+	if p == nil || p.UserID == nil || p.HasScope(ScopeAdmin) {
+		return requested, nil
+	}
+	if requested != nil && *requested != *p.UserID {
+		return nil, fmt.Errorf("user_id %d is %w", *requested, ErrOutsideScope)
+	}
+	return p.UserID, nil
+}
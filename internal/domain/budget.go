@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+)
+
+// Budget limits the expenses of a category in a UTC month, including those
+// of its subcategories, for the whole household.
+type Budget struct {
+	ID         int       `db:"id"`
+	CategoryID int       `db:"category_id"`
+	Amount     float64   `db:"amount"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
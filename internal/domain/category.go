@@ -2,11 +2,14 @@ package domain
 
 import (
 	"database/sql"
+	"slices"
+	"sort"
 	"time"
 )
 
 type Category struct {
 	ID          int          `db:"id"`
+	ParentID    *int         `db:"parent_id"`
 	UpdatedAt   time.Time    `db:"updated_at"`
 	CreatedAt   time.Time    `db:"created_at"`
 	DeletedAt   sql.NullTime `db:"deleted_at"`
@@ -14,3 +17,63 @@ type Category struct {
 	Description string       `db:"description"`
 	Color       string       `db:"color"`
 }
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode
+}
+
+// CategoryTree nests categories under their parents, siblings ordered by ID.
+// Categories whose parent is not among categories are top level.
+func CategoryTree(categories []Category) []CategoryNode {
+	sorted := slices.Clone(categories)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	known := make(map[int]bool, len(sorted))
+	for _, c := range sorted {
+		known[c.ID] = true
+	}
+	var roots []Category
+	children := make(map[int][]Category)
+	for _, c := range sorted {
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var nest func(level []Category) []CategoryNode
+	nest = func(level []Category) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(level))
+		for _, c := range level {
+			nodes = append(nodes, CategoryNode{Category: c, Children: nest(children[c.ID])})
+		}
+		return nodes
+	}
+	return nest(roots)
+}
+
+// CategoryLineage maps the ID of each category to it and its ancestors,
+// nearest first: the categories its totals roll up into.
+func CategoryLineage(categories []Category) map[int][]int {
+	parents := make(map[int]int, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			parents[c.ID] = *c.ParentID
+		}
+	}
+
+	lineage := make(map[int][]int, len(categories))
+	for _, c := range categories {
+		ids := []int{c.ID}
+		for parent, ok := parents[c.ID]; ok && !slices.Contains(ids, parent); parent, ok = parents[parent] {
+			ids = append(ids, parent)
+		}
+		lineage[c.ID] = ids
+	}
+	return lineage
+}
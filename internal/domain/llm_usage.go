@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// LLMUsage is one recorded LLM completion
+type LLMUsage struct {
+	ID               int       `db:"id"`
+	ClientID         string    `db:"client_id"`
+	APIKeyID         *int      `db:"api_key_id"`
+	Endpoint         string    `db:"endpoint"`
+	Model            string    `db:"model"`
+	PromptTokens     int64     `db:"prompt_tokens"`
+	CompletionTokens int64     `db:"completion_tokens"`
+	Cost             float64   `db:"cost"`
+	LatencyMs        int64     `db:"latency_ms"`
+	CreatedAt        time.Time `db:"created_at"`
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MerchantRuleKind is how a merchant rule matches descriptions
+type MerchantRuleKind string
+
+const (
+	// MerchantAlias matches a whole normalized description
+	MerchantAlias MerchantRuleKind = "alias"
+	// MerchantPattern is a regular expression found anywhere in a normalized
+	// description, ignoring case
+	MerchantPattern MerchantRuleKind = "pattern"
+)
+
+// MerchantRule names the merchant behind the descriptions it matches.
+type MerchantRule struct {
+	ID        int              `db:"id"`
+	Kind      MerchantRuleKind `db:"kind"`
+	Match     string           `db:"match"`
+	Merchant  string           `db:"merchant"`
+	CreatedAt time.Time        `db:"created_at"`
+}
+
+// NormalizeDescription uppercases a transaction description and drops its
+// punctuation and the words with digits, such as card, store and trip
+// numbers: "UBER *TRIP 1234 SAO PAULO" becomes "UBER TRIP SAO PAULO".
+func NormalizeDescription(description string) string {
+	words := strings.FieldsFunc(strings.ToUpper(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if !strings.ContainsFunc(word, unicode.IsDigit) {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// MerchantMatcher finds the merchant of descriptions with a set of rules.
+type MerchantMatcher struct {
+	aliases  map[string]string
+	patterns []merchantPattern
+}
+
+type merchantPattern struct {
+	pattern  *regexp.Regexp
+	merchant string
+}
+
+// NewMerchantMatcher compiles rules. Aliases take precedence over patterns,
+// and among several matching rules of a kind the first one wins.
+func NewMerchantMatcher(rules []MerchantRule) (*MerchantMatcher, error) {
+	m := &MerchantMatcher{aliases: make(map[string]string)}
+	for _, rule := range rules {
+		switch rule.Kind {
+		case MerchantAlias:
+			alias := NormalizeDescription(rule.Match)
+			if _, ok := m.aliases[alias]; !ok {
+				m.aliases[alias] = rule.Merchant
+			}
+		case MerchantPattern:
+			pattern, err := CompileMerchantPattern(rule.Match)
+			if err != nil {
+				return nil, fmt.Errorf("merchant rule %d: %w", rule.ID, err)
+			}
+			m.patterns = append(m.patterns, merchantPattern{pattern: pattern, merchant: rule.Merchant})
+		default:
+			return nil, fmt.Errorf("merchant rule %d: unknown kind %q", rule.ID, rule.Kind)
+		}
+	}
+	return m, nil
+}
+
+// CompileMerchantPattern compiles the regular expression of a pattern rule.
+func CompileMerchantPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Merchant returns the merchant of description. Without a matching rule it is
+// the normalized text before the first "*", which card processors put between
+// the merchant and the purchase details, or the whole normalized description.
+// It is empty when the description has no words without digits.
+func (m *MerchantMatcher) Merchant(description string) string {
+	normalized := NormalizeDescription(description)
+	if merchant, ok := m.aliases[normalized]; ok {
+		return merchant
+	}
+	for _, p := range m.patterns {
+		if p.pattern.MatchString(normalized) {
+			return p.merchant
+		}
+	}
+
+	if before, _, found := strings.Cut(description, "*"); found {
+		if merchant := NormalizeDescription(before); merchant != "" {
+			return merchant
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "testing"
+
+func TestNormalizeDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"UBER *TRIP 1234 SAO PAULO", "UBER TRIP SAO PAULO"},
+		{"uber *trip 1234 sao paulo", "UBER TRIP SAO PAULO"},
+		{"  Padaria   São-João  ", "PADARIA SÃO JOÃO"},
+		{"IFOOD*IFD3R45 RESTAURANTE", "IFOOD RESTAURANTE"},
+		{"PAG*JoseDaSilva", "PAG JOSEDASILVA"},
+		{"AMAZON.COM.BR", "AMAZON COM BR"},
+		{"12345 6789", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDescription(tt.description); got != tt.want {
+			t.Errorf("NormalizeDescription(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestMerchantMatcherPrecedence(t *testing.T) {
+	// Rules come oldest first, as the repository returns them.
+	rules := []MerchantRule{
+		{ID: 1, Kind: MerchantPattern, Match: `^UBER\b`, Merchant: "Uber"},
+		{ID: 2, Kind: MerchantAlias, Match: "uber *eats 99 sao paulo", Merchant: "Uber Eats"},
+		{ID: 3, Kind: MerchantPattern, Match: `uber trip`, Merchant: "Uber Trip"},
+		{ID: 4, Kind: MerchantAlias, Match: "PADARIA SAO JOAO", Merchant: "Bakery"},
+		{ID: 5, Kind: MerchantAlias, Match: "padaria sao joao 22", Merchant: "Newer Bakery"},
+		{ID: 6, Kind: MerchantPattern, Match: `padaria`, Merchant: "Any Bakery"},
+	}
+	matcher, err := NewMerchantMatcher(rules)
+	if err != nil {
+		t.Fatalf("NewMerchantMatcher: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{"alias over an older pattern", "UBER *EATS 1234 SAO PAULO", "Uber Eats"},
+		{"older pattern over newer", "UBER *TRIP 1234 SAO PAULO", "Uber"},
+		{"older alias over newer", "Padaria Sao Joao 0042", "Bakery"},
+		{"alias over a newer pattern", "PADARIA SAO JOAO", "Bakery"},
+		{"pattern ignores case", "padaria do bairro", "Any Bakery"},
+		{"text before the star", "IFOOD *IFD3R45 RESTAURANTE", "IFOOD"},
+		{"star without text before", "*LOJA 12 CENTRO", "LOJA CENTRO"},
+		{"whole normalized description", "Mercado Livre 8812", "MERCADO LIVRE"},
+		{"only numbers", "1234 5678", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matcher.Merchant(tt.description); got != tt.want {
+				t.Errorf("Merchant(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMerchantMatcherRejectsInvalidRules(t *testing.T) {
+	tests := []MerchantRule{
+		{ID: 1, Kind: MerchantPattern, Match: "(unclosed", Merchant: "Broken"},
+		{ID: 2, Kind: "prefix", Match: "UBER", Merchant: "Uber"},
+	}
+	for _, rule := range tests {
+		if _, err := NewMerchantMatcher([]MerchantRule{rule}); err == nil {
+			t.Errorf("NewMerchantMatcher accepted %+v", rule)
+		}
+	}
+}
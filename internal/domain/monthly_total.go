@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// MonthlyTotal is the sum and count of the transactions of one category,
+// type, subtype and user in one UTC month. An empty subtype is totalled as
+// none.
+type MonthlyTotal struct {
+	Month      time.Time
+	CategoryID int
+	Type       Type
+	Subtype    *string
+	UserID     int
+	Amount     float64
+	Count      int
+}
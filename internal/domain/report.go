@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+)
+
+// ReportPeriod is how often a report is produced
+type ReportPeriod string
+
+const (
+	ReportWeekly  ReportPeriod = "weekly"
+	ReportMonthly ReportPeriod = "monthly"
+)
+
+// Report summarizes the transactions of one week or month, from PeriodStart
+// inclusive to PeriodEnd exclusive, against the period before it.
+type Report struct {
+	ID          int           `db:"id"`
+	Period      ReportPeriod  `db:"period"`
+	PeriodStart time.Time     `db:"period_start"`
+	PeriodEnd   time.Time     `db:"period_end"`
+	Summary     ReportSummary `db:"summary"`
+	Narrative   *string       `db:"narrative"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// ReportSummary is stored as JSON, so unlike other domain types it carries
+// json tags.
+type ReportSummary struct {
+	Income          float64             `json:"income"`
+	Expense         float64             `json:"expense"`
+	PreviousIncome  float64             `json:"previous_income"`
+	PreviousExpense float64             `json:"previous_expense"`
+	Categories      []ReportCategory    `json:"categories"`
+	TopTransactions []ReportTransaction `json:"top_transactions"`
+	Budgets         []ReportBudget      `json:"budgets"`
+}
+
+// ReportCategory compares the expenses of a category, including those of its
+// subcategories, with the previous period. ChangePercent is nil when there
+// were none in the previous period.
+type ReportCategory struct {
+	CategoryID       int      `json:"category_id"`
+	CategoryName     string   `json:"category_name"`
+	ParentCategoryID *int     `json:"parent_category_id"`
+	Total            float64  `json:"total"`
+	PreviousTotal    float64  `json:"previous_total"`
+	ChangePercent    *float64 `json:"change_percent"`
+}
+
+// ReportBudget compares a budget with the expenses of its category, including
+// those of its subcategories, in the UTC month the period ends in, up to the
+// end of the period. Remaining is negative once the budget is exceeded.
+type ReportBudget struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Amount       float64 `json:"amount"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"`
+	Exceeded     bool    `json:"exceeded"`
+}
+
+type ReportTransaction struct {
+	ID           int        `json:"id"`
+	Date         *time.Time `json:"date"`
+	Amount       float64    `json:"amount"`
+	CategoryID   int        `json:"category_id"`
+	CategoryName string     `json:"category_name"`
+	Description  string     `json:"description"`
+}
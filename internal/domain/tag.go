@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Tag is a free-form label on transactions, cutting across categories.
+type Tag struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// NormalizeTagName returns name as tags are stored: trimmed and lowercase.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
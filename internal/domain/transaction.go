@@ -27,4 +27,5 @@ type Transaction struct {
 	CreatedAt   time.Time  `db:"created_at"`
 	Description string     `db:"description"`
 	IsRecurring bool       `db:"is_recurring"`
+	Tags        []string   `db:"tags"`
 }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType is something that happened which webhooks can subscribe to
+type EventType string
+
+const (
+	EventBudgetExceeded  EventType = "budget.exceeded"
+	EventAnomalyDetected EventType = "anomaly.detected"
+	EventReportReady     EventType = "report.ready"
+	// EventPing is only sent by the test endpoint, to check a receiver end to end
+	EventPing EventType = "ping"
+)
+
+// EventTypes are the events webhooks can subscribe to. Only events the
+// service actually publishes are listed, so subscribing to anything else is
+// rejected rather than silently never delivered.
+var EventTypes = []EventType{EventBudgetExceeded, EventAnomalyDetected, EventReportReady}
+
+type Webhook struct {
+	ID        int         `db:"id"`
+	URL       string      `db:"url"`
+	Secret    string      `db:"secret" json:"-"`
+	Events    []EventType `db:"events"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants event. Every webhook gets pings.
+func (w *Webhook) Subscribes(event EventType) bool {
+	if event == EventPing {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is where a webhook delivery is in its lifecycle
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to one webhook. Payload
+// is the exact body that is signed and posted on every attempt.
+type WebhookDelivery struct {
+	ID             int             `db:"id"`
+	WebhookID      int             `db:"webhook_id"`
+	EventID        string          `db:"event_id"`
+	EventType      EventType       `db:"event_type"`
+	Payload        json.RawMessage `db:"payload"`
+	Status         DeliveryStatus  `db:"status"`
+	Attempts       int             `db:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code"`
+	LastError      *string         `db:"last_error"`
+	CreatedAt      time.Time       `db:"created_at"`
+	DeliveredAt    *time.Time      `db:"delivered_at"`
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey struct{}
+
+// redacted replaces sensitive values in logs unless sensitive logging is enabled
+const redacted = "[REDACTED]"
+
+var logSensitiveData bool
+
+// Setup installs a JSON slog logger writing to stdout as the default logger,
+// which log.Printf also goes through. level is one of debug, info, warn or
+// error. Financial data such as query results is only logged when
+// sensitiveData is true.
+func Setup(level string, sensitiveData bool) error {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+
+	logSensitiveData = sensitiveData
+	slog.SetDefault(New(os.Stdout, slogLevel))
+	return nil
+}
+
+// New returns a JSON logger that adds the request id found in the context of
+// each record.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&contextHandler{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// WithRequestID returns a context carrying the request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// RequestID returns the request id in ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// Sensitive wraps a value that may contain financial data, redacting it unless
+// sensitive logging was enabled.
+func Sensitive(key string, value any) slog.Attr {
+	if !logSensitiveData {
+		return slog.String(key, redacted)
+	}
+	return slog.Any(key, value)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
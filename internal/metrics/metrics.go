@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"time"
+
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "analytics"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPResponseCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_response_cache_total",
+		Help:      "Cacheable HTTP requests by route and result (hit, miss or not_modified).",
+	}, []string{"route", "result"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by stage.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"stage"})
+
+	LLMCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_calls_total",
+		Help:      "LLM completions by model and outcome.",
+	}, []string{"model", "outcome"})
+
+	LLMCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_call_duration_seconds",
+		Help:      "LLM completion latency by model.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"model"})
+
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens consumed by model and kind (prompt or completion).",
+	}, []string{"model", "kind"})
+
+	WebhookAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook delivery attempts by event type and outcome (succeeded, retrying or failed).",
+	}, []string{"event", "outcome"})
+
+	// GRPCServer counts gRPC calls and messages by service, method and code,
+	// and times the calls, through its interceptors.
+	GRPCServer = grpcprom.NewServerMetrics(
+		grpcprom.WithServerCounterOptions(grpcprom.WithNamespace(namespace)),
+		grpcprom.WithServerHandlingTimeHistogram(
+			grpcprom.WithHistogramNamespace(namespace),
+			grpcprom.WithHistogramBuckets([]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}),
+		),
+	)
+)
+
+func init() {
+	prometheus.MustRegister(GRPCServer)
+}
+
+// ObserveDBQuery starts timing a database query for stage. Call the returned
+// function when the query is done:
+//
+//	defer metrics.ObserveDBQuery("transactions")()
+func ObserveDBQuery(stage string) func() {
+	start := time.Now()
+	return func() {
+		DBQueryDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveLLMCall records a completion and its outcome.
+func ObserveLLMCall(model string, duration time.Duration, promptTokens int64, completionTokens int64, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	LLMCalls.WithLabelValues(model, outcome).Inc()
+	LLMCallDuration.WithLabelValues(model).Observe(duration.Seconds())
+	LLMTokens.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	LLMTokens.WithLabelValues(model, "completion").Add(float64(completionTokens))
+}
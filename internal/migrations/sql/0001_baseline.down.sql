@@ -0,0 +1 @@
+-- The baseline tables belong to the transactions app and are never dropped here.
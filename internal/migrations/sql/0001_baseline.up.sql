@@ -0,0 +1,36 @@
+-- The tables written by the transactions app. They are only created when
+-- missing, so databases that already have them adopt this migration as is.
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'transaction_type') THEN
+		CREATE TYPE transaction_type AS ENUM ('income', 'expense');
+	END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS categories (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	color VARCHAR(50) NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id SERIAL PRIMARY KEY,
+	migrated_id INTEGER,
+	is_recurring BOOLEAN NOT NULL DEFAULT FALSE,
+	category_id INTEGER,
+	amount DECIMAL(12, 2) NOT NULL,
+	type transaction_type NOT NULL,
+	description TEXT,
+	date TIMESTAMP WITH TIME ZONE,
+	frequency VARCHAR(50),
+	start_date DATE,
+	end_date DATE,
+	created_by_id INTEGER NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	scopes TEXT[] NOT NULL,
+	user_id INTEGER,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP WITH TIME ZONE,
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	daily_token_quota BIGINT,
+	monthly_token_quota BIGINT,
+	daily_cost_quota DECIMAL(12, 4),
+	monthly_cost_quota DECIMAL(12, 4)
+);
+
+-- Tables created before quotas existed
+ALTER TABLE api_keys
+	ADD COLUMN IF NOT EXISTS daily_token_quota BIGINT,
+	ADD COLUMN IF NOT EXISTS monthly_token_quota BIGINT,
+	ADD COLUMN IF NOT EXISTS daily_cost_quota DECIMAL(12, 4),
+	ADD COLUMN IF NOT EXISTS monthly_cost_quota DECIMAL(12, 4);
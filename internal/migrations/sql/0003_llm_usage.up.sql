@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS llm_usage (
+	id SERIAL PRIMARY KEY,
+	client_id TEXT NOT NULL,
+	api_key_id INTEGER,
+	endpoint TEXT NOT NULL DEFAULT '',
+	model TEXT NOT NULL,
+	prompt_tokens BIGINT NOT NULL,
+	completion_tokens BIGINT NOT NULL,
+	cost DECIMAL(12, 6) NOT NULL,
+	latency_ms BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tables created before endpoints and latencies were recorded
+ALTER TABLE llm_usage
+	ADD COLUMN IF NOT EXISTS endpoint TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS latency_ms BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS llm_usage_client_id_created_at_idx ON llm_usage (client_id, created_at);
+CREATE INDEX IF NOT EXISTS llm_usage_created_at_idx ON llm_usage (created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reports (
+	id SERIAL PRIMARY KEY,
+	period TEXT NOT NULL,
+	period_start DATE NOT NULL,
+	period_end DATE NOT NULL,
+	summary JSONB NOT NULL,
+	narrative TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (period, period_start)
+);
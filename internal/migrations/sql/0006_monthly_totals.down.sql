@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS transactions_monthly_totals_truncate ON transactions;
+DROP TRIGGER IF EXISTS transactions_monthly_totals_stale ON transactions;
+DROP FUNCTION IF EXISTS clear_transaction_monthly_totals();
+DROP FUNCTION IF EXISTS mark_transaction_month_stale();
+DROP TABLE IF EXISTS transaction_monthly_totals_stale;
+DROP TABLE IF EXISTS transaction_monthly_totals;
@@ -0,0 +1,60 @@
+-- Transaction sums and counts by UTC month, category, type and user. Months
+-- touched by a write are marked stale by a trigger and recomputed before the
+-- next read, so the transactions app needs no changes. The trigger functions
+-- run as their owner, so its database user needs no grants on these tables.
+CREATE TABLE transaction_monthly_totals (
+	month DATE NOT NULL,
+	category_id INTEGER,
+	type transaction_type NOT NULL,
+	created_by_id INTEGER NOT NULL,
+	amount DECIMAL(14, 2) NOT NULL,
+	count INTEGER NOT NULL
+);
+
+CREATE INDEX transaction_monthly_totals_month_idx ON transaction_monthly_totals (month);
+
+CREATE TABLE transaction_monthly_totals_stale (
+	month DATE PRIMARY KEY
+);
+
+-- Marking updates an existing mark rather than skipping it, so the mark stays
+-- locked until the write commits and a refresh deleting it waits to see it.
+-- This orders a refresh after the writes it covers, not after other refreshes;
+-- 0013 replaces these marks and 0014 serializes refreshes.
+CREATE FUNCTION mark_transaction_month_stale() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', OLD.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT (month) DO UPDATE SET month = EXCLUDED.month;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', NEW.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT (month) DO UPDATE SET month = EXCLUDED.month;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path FROM CURRENT;
+
+CREATE FUNCTION clear_transaction_monthly_totals() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM transaction_monthly_totals;
+	DELETE FROM transaction_monthly_totals_stale;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path FROM CURRENT;
+
+CREATE TRIGGER transactions_monthly_totals_stale
+	AFTER INSERT OR UPDATE OF date, amount, type, category_id, created_by_id OR DELETE ON transactions
+	FOR EACH ROW EXECUTE FUNCTION mark_transaction_month_stale();
+
+CREATE TRIGGER transactions_monthly_totals_truncate
+	AFTER TRUNCATE ON transactions
+	FOR EACH STATEMENT EXECUTE FUNCTION clear_transaction_monthly_totals();
+
+INSERT INTO transaction_monthly_totals (month, category_id, type, created_by_id, amount, count)
+SELECT date_trunc('month', date AT TIME ZONE 'UTC')::date, category_id, type, created_by_id, SUM(amount), COUNT(*)
+FROM transactions
+WHERE date IS NOT NULL
+GROUP BY 1, 2, 3, 4;
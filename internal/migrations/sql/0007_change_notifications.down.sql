@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS categories_notify_change ON categories;
+DROP TRIGGER IF EXISTS transactions_notify_change ON transactions;
+DROP FUNCTION IF EXISTS notify_analytics_change();
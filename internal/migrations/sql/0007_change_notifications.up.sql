@@ -0,0 +1,16 @@
+-- Notify analytics_changes with the table name after every statement writing
+-- to the tables analytics caches. Notifications are only delivered on commit.
+CREATE FUNCTION notify_analytics_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('analytics_changes', TG_TABLE_NAME);
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_notify_change
+	AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON transactions
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_analytics_change();
+
+CREATE TRIGGER categories_notify_change
+	AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON categories
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_analytics_change();
@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS category_subtree(INTEGER[]);
+DROP TRIGGER IF EXISTS categories_check_parent ON categories;
+DROP FUNCTION IF EXISTS check_category_parent();
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
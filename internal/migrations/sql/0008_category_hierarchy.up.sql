@@ -0,0 +1,36 @@
+-- Categories can have a parent, whose totals include those of its
+-- subcategories. Deleting a parent makes its children top level.
+ALTER TABLE categories ADD COLUMN parent_id INTEGER REFERENCES categories (id) ON DELETE SET NULL;
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+-- Rejects parents that would make a category its own ancestor.
+CREATE FUNCTION check_category_parent() RETURNS trigger AS $$
+BEGIN
+	IF NEW.parent_id IS NOT NULL AND EXISTS (
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = NEW.parent_id
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT 1 FROM ancestors WHERE id = NEW.id
+	) THEN
+		RAISE EXCEPTION 'category % cannot be its own ancestor', NEW.id USING ERRCODE = 'check_violation';
+	END IF;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER categories_check_parent
+	BEFORE INSERT OR UPDATE OF parent_id ON categories
+	FOR EACH ROW EXECUTE FUNCTION check_category_parent();
+
+-- The given categories and all their subcategories.
+CREATE FUNCTION category_subtree(root_ids INTEGER[]) RETURNS SETOF INTEGER AS $$
+	WITH RECURSIVE subtree AS (
+		SELECT unnest(root_ids) AS id
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT id FROM subtree
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,24 @@
+-- Free-form labels cutting across categories, such as "vacation-2026". Names
+-- are stored lowercase.
+CREATE TABLE tags (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(50) NOT NULL UNIQUE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE transaction_tags (
+	transaction_id INTEGER NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+	tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX transaction_tags_tag_id_idx ON transaction_tags (tag_id);
+
+CREATE TRIGGER tags_notify_change
+	AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON tags
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_analytics_change();
+
+CREATE TRIGGER transaction_tags_notify_change
+	AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON transaction_tags
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_analytics_change();
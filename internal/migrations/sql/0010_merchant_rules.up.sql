@@ -0,0 +1,23 @@
+-- Rules naming the merchant behind transaction descriptions, which are
+-- matched after normalization (uppercase, no punctuation, no words with
+-- digits). An alias is a whole normalized description, a pattern a regular
+-- expression found anywhere in it.
+CREATE TABLE merchant_rules (
+	id SERIAL PRIMARY KEY,
+	kind VARCHAR(10) NOT NULL CHECK (kind IN ('alias', 'pattern')),
+	match TEXT NOT NULL,
+	merchant VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO merchant_rules (kind, match, merchant) VALUES
+	('pattern', '^UBER\b', 'Uber'),
+	('pattern', '^(IFD|IFOOD)\b', 'iFood'),
+	('pattern', '^(AMAZON|AMZN)\b', 'Amazon'),
+	('pattern', '^NETFLIX\b', 'Netflix'),
+	('pattern', '^SPOTIFY\b', 'Spotify'),
+	('pattern', '^MERCADO ?LIVRE\b', 'Mercado Livre');
+
+CREATE TRIGGER merchant_rules_notify_change
+	AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON merchant_rules
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_analytics_change();
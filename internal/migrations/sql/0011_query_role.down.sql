@@ -0,0 +1,11 @@
+-- analytics_query is kept, as roles are shared by the cluster and it may have
+-- been given a login.
+DROP SCHEMA IF EXISTS nl_query CASCADE;
+DROP TABLE IF EXISTS query_scope_secret;
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'analytics_query') THEN
+		ALTER ROLE analytics_query RESET ALL;
+	END IF;
+END
+$$;
@@ -0,0 +1,74 @@
+-- Natural language queries run as analytics_query, which can only read the
+-- views of the nl_query schema. The migrating user needs CREATEROLE; give the
+-- role a password and LOGIN to use it in QUERY_DATABASE_URL.
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'analytics_query') THEN
+		CREATE ROLE analytics_query NOLOGIN;
+	END IF;
+END
+$$;
+
+ALTER ROLE analytics_query SET search_path = nl_query;
+ALTER ROLE analytics_query SET default_transaction_read_only = on;
+
+-- Signs the analytics.query_scope setting, so the role cannot widen its scope
+-- by setting it itself. Only the owner can read it.
+CREATE TABLE query_scope_secret (
+	secret TEXT NOT NULL
+);
+INSERT INTO query_scope_secret (secret) VALUES (replace(gen_random_uuid()::text || gen_random_uuid()::text, '-', ''));
+
+CREATE SCHEMA nl_query;
+
+-- The user whose transactions the query may read, from analytics.query_scope:
+-- "<user id>.<signature>", or "all.<signature>" for every user, which is NULL.
+-- Anything else fails the query.
+CREATE FUNCTION nl_query.scoped_user_id() RETURNS INTEGER AS $$
+DECLARE
+	scope TEXT := current_setting('analytics.query_scope', true);
+	subject TEXT := split_part(scope, '.', 1);
+	key TEXT;
+BEGIN
+	SELECT secret INTO key FROM public.query_scope_secret;
+	IF scope IS NULL OR scope <> subject || '.' || encode(sha256(convert_to(
+		key || encode(sha256(convert_to(key || subject, 'UTF8')), 'hex'), 'UTF8'
+	)), 'hex') THEN
+		RAISE EXCEPTION 'query scope is missing or invalid' USING ERRCODE = 'insufficient_privilege';
+	END IF;
+	IF subject = 'all' THEN
+		RETURN NULL;
+	END IF;
+	RETURN subject::INTEGER;
+END
+$$ LANGUAGE plpgsql STABLE SECURITY DEFINER SET search_path = pg_catalog, public;
+
+CREATE VIEW nl_query.transactions WITH (security_barrier) AS
+	SELECT t.*
+	FROM public.transactions t
+	WHERE (SELECT nl_query.scoped_user_id()) IS NULL OR t.created_by_id = (SELECT nl_query.scoped_user_id());
+
+CREATE VIEW nl_query.categories AS
+	SELECT id, parent_id, name, description, color, created_at, updated_at, deleted_at
+	FROM public.categories;
+
+CREATE VIEW nl_query.tags AS
+	SELECT id, name, created_at
+	FROM public.tags;
+
+CREATE VIEW nl_query.transaction_tags WITH (security_barrier) AS
+	SELECT tt.transaction_id, tt.tag_id, tt.created_at
+	FROM public.transaction_tags tt
+	WHERE tt.transaction_id IN (SELECT id FROM nl_query.transactions);
+
+CREATE FUNCTION nl_query.category_subtree(root_ids INTEGER[]) RETURNS SETOF INTEGER AS $$
+	WITH RECURSIVE subtree AS (
+		SELECT unnest(root_ids) AS id
+		UNION
+		SELECT c.id FROM nl_query.categories c JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT id FROM subtree
+$$ LANGUAGE sql STABLE;
+
+GRANT USAGE ON SCHEMA nl_query TO analytics_query;
+GRANT SELECT ON ALL TABLES IN SCHEMA nl_query TO analytics_query;
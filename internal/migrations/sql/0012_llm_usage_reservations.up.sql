@@ -0,0 +1,12 @@
+-- Usage held for LLM requests in flight, counted against the quotas until the
+-- request records what it used. Reservations left by a crashed process stop
+-- counting once they expire.
+CREATE TABLE IF NOT EXISTS llm_usage_reservations (
+	id SERIAL PRIMARY KEY,
+	client_id TEXT NOT NULL,
+	tokens BIGINT NOT NULL,
+	cost DECIMAL(12, 6) NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS llm_usage_reservations_client_id_idx ON llm_usage_reservations (client_id);
@@ -0,0 +1,25 @@
+CREATE OR REPLACE FUNCTION mark_transaction_month_stale() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', OLD.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT (month) DO UPDATE SET month = EXCLUDED.month;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', NEW.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT (month) DO UPDATE SET month = EXCLUDED.month;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path FROM CURRENT;
+
+ALTER TABLE transaction_monthly_totals_stale DROP CONSTRAINT transaction_monthly_totals_stale_pkey;
+
+DELETE FROM transaction_monthly_totals_stale a
+USING transaction_monthly_totals_stale b
+WHERE a.month = b.month AND a.xact > b.xact;
+
+ALTER TABLE transaction_monthly_totals_stale
+	DROP COLUMN xact,
+	ADD PRIMARY KEY (month);
@@ -0,0 +1,27 @@
+-- Stale marks were one row per month, updated by every write so a refresh
+-- deleting it waited for the write to commit. That serialized writers of a
+-- month and made them wait on the refresh in turn. Marks are now one row per
+-- month and writing transaction, inserted without touching anyone else's: a
+-- refresh only deletes the marks of committed writes, and a write still in
+-- flight leaves its mark for the next refresh.
+ALTER TABLE transaction_monthly_totals_stale DROP CONSTRAINT transaction_monthly_totals_stale_pkey;
+
+ALTER TABLE transaction_monthly_totals_stale
+	ADD COLUMN xact BIGINT NOT NULL DEFAULT txid_current(),
+	ADD PRIMARY KEY (month, xact);
+
+CREATE OR REPLACE FUNCTION mark_transaction_month_stale() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', OLD.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT DO NOTHING;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.date IS NOT NULL THEN
+		INSERT INTO transaction_monthly_totals_stale (month)
+		VALUES (date_trunc('month', NEW.date AT TIME ZONE 'UTC')::date)
+		ON CONFLICT DO NOTHING;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path FROM CURRENT;
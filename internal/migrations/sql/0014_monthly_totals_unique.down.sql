@@ -0,0 +1 @@
+DROP INDEX IF EXISTS transaction_monthly_totals_group_idx;
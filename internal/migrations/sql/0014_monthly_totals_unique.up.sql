@@ -0,0 +1,15 @@
+-- Two refreshes of the same month could both clear it and both insert its
+-- totals, doubling them. Refreshes now take an advisory lock, and a group can
+-- only have one row, so a refresh that slips past the lock replaces totals
+-- instead of adding to them. Totals doubled before are recomputed here.
+DELETE FROM transaction_monthly_totals;
+
+INSERT INTO transaction_monthly_totals (month, category_id, type, created_by_id, amount, count)
+SELECT date_trunc('month', date AT TIME ZONE 'UTC')::date, category_id, type, created_by_id, SUM(amount), COUNT(*)
+FROM transactions
+WHERE date IS NOT NULL
+GROUP BY 1, 2, 3, 4;
+
+-- Categories are optional, and no category has id 0.
+CREATE UNIQUE INDEX transaction_monthly_totals_group_idx
+	ON transaction_monthly_totals (month, (COALESCE(category_id, 0)), type, created_by_id);
@@ -0,0 +1,22 @@
+-- transactions.subtype is left alone, the transactions app writes it.
+DROP TRIGGER transactions_monthly_totals_stale ON transactions;
+
+CREATE TRIGGER transactions_monthly_totals_stale
+	AFTER INSERT OR UPDATE OF date, amount, type, category_id, created_by_id OR DELETE ON transactions
+	FOR EACH ROW EXECUTE FUNCTION mark_transaction_month_stale();
+
+DROP INDEX transaction_monthly_totals_group_idx;
+
+ALTER TABLE transaction_monthly_totals DROP COLUMN subtype;
+
+DELETE FROM transaction_monthly_totals;
+DELETE FROM transaction_monthly_totals_stale;
+
+INSERT INTO transaction_monthly_totals (month, category_id, type, created_by_id, amount, count)
+SELECT date_trunc('month', date AT TIME ZONE 'UTC')::date, category_id, type, created_by_id, SUM(amount), COUNT(*)
+FROM transactions
+WHERE date IS NOT NULL
+GROUP BY 1, 2, 3, 4;
+
+CREATE UNIQUE INDEX transaction_monthly_totals_group_idx
+	ON transaction_monthly_totals (month, (COALESCE(category_id, 0)), type, created_by_id);
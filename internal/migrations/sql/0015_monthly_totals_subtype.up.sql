@@ -0,0 +1,32 @@
+-- Monthly totals are also grouped by subtype. The transactions app writes
+-- subtype, but the baseline did not create it for databases started here.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtype VARCHAR(50);
+
+-- The view's columns were fixed when it was created, and may lack subtype.
+CREATE OR REPLACE VIEW nl_query.transactions WITH (security_barrier) AS
+	SELECT t.*
+	FROM public.transactions t
+	WHERE (SELECT nl_query.scoped_user_id()) IS NULL OR t.created_by_id = (SELECT nl_query.scoped_user_id());
+
+ALTER TABLE transaction_monthly_totals ADD COLUMN subtype VARCHAR(50);
+
+DROP INDEX transaction_monthly_totals_group_idx;
+
+DELETE FROM transaction_monthly_totals;
+DELETE FROM transaction_monthly_totals_stale;
+
+INSERT INTO transaction_monthly_totals (month, category_id, type, subtype, created_by_id, amount, count)
+SELECT date_trunc('month', date AT TIME ZONE 'UTC')::date, category_id, type, NULLIF(subtype, ''), created_by_id, SUM(amount), COUNT(*)
+FROM transactions
+WHERE date IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5;
+
+-- Subtypes are optional too, and an empty one is totalled as none.
+CREATE UNIQUE INDEX transaction_monthly_totals_group_idx
+	ON transaction_monthly_totals (month, (COALESCE(category_id, 0)), type, (COALESCE(subtype, '')), created_by_id);
+
+DROP TRIGGER transactions_monthly_totals_stale ON transactions;
+
+CREATE TRIGGER transactions_monthly_totals_stale
+	AFTER INSERT OR UPDATE OF date, amount, type, subtype, category_id, created_by_id OR DELETE ON transactions
+	FOR EACH ROW EXECUTE FUNCTION mark_transaction_month_stale();
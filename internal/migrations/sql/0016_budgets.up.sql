@@ -0,0 +1,18 @@
+-- Monthly expense limits of categories, including their subcategories, for
+-- the whole household. A budget crossed in a month is recorded in
+-- budget_alerts, so budget.exceeded is only published once per budget and
+-- month.
+CREATE TABLE budgets (
+	id SERIAL PRIMARY KEY,
+	category_id INTEGER NOT NULL UNIQUE,
+	amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE budget_alerts (
+	budget_id INTEGER NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+	month DATE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (budget_id, month)
+);
@@ -0,0 +1,7 @@
+-- The transactions anomaly.detected was published for, so each is only
+-- reported once. Transactions are not referenced, as the transactions app
+-- owns them and may delete them.
+CREATE TABLE anomaly_alerts (
+	transaction_id INTEGER PRIMARY KEY,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
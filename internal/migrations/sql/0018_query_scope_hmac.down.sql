@@ -0,0 +1,19 @@
+-- pgcrypto is kept, as other objects may have come to depend on it.
+CREATE OR REPLACE FUNCTION nl_query.scoped_user_id() RETURNS INTEGER AS $$
+DECLARE
+	scope TEXT := current_setting('analytics.query_scope', true);
+	subject TEXT := split_part(scope, '.', 1);
+	key TEXT;
+BEGIN
+	SELECT secret INTO key FROM public.query_scope_secret;
+	IF scope IS NULL OR scope <> subject || '.' || encode(sha256(convert_to(
+		key || encode(sha256(convert_to(key || subject, 'UTF8')), 'hex'), 'UTF8'
+	)), 'hex') THEN
+		RAISE EXCEPTION 'query scope is missing or invalid' USING ERRCODE = 'insufficient_privilege';
+	END IF;
+	IF subject = 'all' THEN
+		RETURN NULL;
+	END IF;
+	RETURN subject::INTEGER;
+END
+$$ LANGUAGE plpgsql STABLE SECURITY DEFINER SET search_path = pg_catalog, public;
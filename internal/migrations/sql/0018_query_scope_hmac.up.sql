@@ -0,0 +1,37 @@
+-- Signs analytics.query_scope with HMAC-SHA256 instead of nested hashes.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- The user whose transactions the query may read, from analytics.query_scope:
+-- "<user id>.<signature>", or "all.<signature>" for every user, which is NULL.
+-- The signature is the hex HMAC-SHA256 of the subject keyed with the secret,
+-- compared byte by byte without stopping at the first difference. Anything
+-- else fails the query.
+CREATE OR REPLACE FUNCTION nl_query.scoped_user_id() RETURNS INTEGER AS $$
+DECLARE
+	scope TEXT := current_setting('analytics.query_scope', true);
+	subject TEXT := split_part(scope, '.', 1);
+	key TEXT;
+	expected BYTEA;
+	signature BYTEA;
+	difference INTEGER := 0;
+BEGIN
+	IF scope IS NULL OR scope !~ '^[^.]+\.[0-9a-f]{64}$' THEN
+		RAISE EXCEPTION 'query scope is missing or invalid' USING ERRCODE = 'insufficient_privilege';
+	END IF;
+
+	SELECT secret INTO key FROM public.query_scope_secret;
+	expected := hmac(convert_to(subject, 'UTF8'), convert_to(key, 'UTF8'), 'sha256');
+	signature := decode(split_part(scope, '.', 2), 'hex');
+	FOR i IN 0 .. length(expected) - 1 LOOP
+		difference := difference | (get_byte(expected, i) # get_byte(signature, i));
+	END LOOP;
+	IF difference <> 0 THEN
+		RAISE EXCEPTION 'query scope is missing or invalid' USING ERRCODE = 'insufficient_privilege';
+	END IF;
+
+	IF subject = 'all' THEN
+		RETURN NULL;
+	END IF;
+	RETURN subject::INTEGER;
+END
+$$ LANGUAGE plpgsql STABLE SECURITY DEFINER SET search_path = pg_catalog, public;
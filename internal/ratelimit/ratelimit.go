@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucketIdleTTL is how long an untouched bucket is kept before being dropped
+const bucketIdleTTL = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter is a token bucket per client, safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	rate      float64
+	burst     float64
+	lastSweep time.Time
+}
+
+// Allow takes a token from the client's bucket. When the bucket is empty it
+// returns how long until the next token is available.
+func (l *Limiter) Allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > bucketIdleTTL {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.lastSeen) > bucketIdleTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*l.rate)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// New allows each client requestsPerMinute requests on average, with bursts of
+// up to burst requests.
+func New(requestsPerMinute int, burst int) *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(burst),
+	}
+}
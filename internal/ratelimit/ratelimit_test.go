@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	type step struct {
+		client      string
+		after       time.Duration
+		wantAllowed bool
+		wantWait    time.Duration
+	}
+	tests := []struct {
+		name              string
+		requestsPerMinute int
+		burst             int
+		steps             []step
+	}{
+		{
+			name:              "burst then empty",
+			requestsPerMinute: 60,
+			burst:             2,
+			steps: []step{
+				{"a", 0, true, 0},
+				{"a", 0, true, 0},
+				{"a", 0, false, time.Second},
+			},
+		},
+		{
+			name:              "refills at the rate",
+			requestsPerMinute: 60,
+			burst:             2,
+			steps: []step{
+				{"a", 0, true, 0},
+				{"a", 0, true, 0},
+				{"a", 500 * time.Millisecond, false, 500 * time.Millisecond},
+				{"a", time.Second, true, 0},
+				{"a", time.Second, false, time.Second},
+			},
+		},
+		{
+			name:              "refill is capped at the burst",
+			requestsPerMinute: 60,
+			burst:             2,
+			steps: []step{
+				{"a", 0, true, 0},
+				{"a", 10 * time.Second, true, 0},
+				{"a", 10 * time.Second, true, 0},
+				{"a", 10 * time.Second, false, time.Second},
+			},
+		},
+		{
+			name:              "slow rate",
+			requestsPerMinute: 30,
+			burst:             1,
+			steps: []step{
+				{"a", 0, true, 0},
+				{"a", time.Second, false, time.Second},
+				{"a", 2 * time.Second, true, 0},
+			},
+		},
+		{
+			name:              "clients have their own buckets",
+			requestsPerMinute: 60,
+			burst:             1,
+			steps: []step{
+				{"a", 0, true, 0},
+				{"a", 0, false, time.Second},
+				{"b", 0, true, 0},
+			},
+		},
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New(tt.requestsPerMinute, tt.burst)
+			for i, s := range tt.steps {
+				allowed, wait := limiter.Allow(s.client, start.Add(s.after))
+				if allowed != s.wantAllowed || wait != s.wantWait {
+					t.Fatalf("step %d: Allow(%q, +%s) = %v, %s; want %v, %s", i, s.client, s.after, allowed, wait, s.wantAllowed, s.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterDropsIdleBuckets(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(60, 1)
+	limiter.Allow("a", start)
+	limiter.Allow("b", start.Add(bucketIdleTTL))
+
+	limiter.Allow("b", start.Add(bucketIdleTTL+time.Second))
+	if _, ok := limiter.buckets["a"]; ok {
+		t.Errorf("bucket of a kept after %s idle", bucketIdleTTL+time.Second)
+	}
+	if _, ok := limiter.buckets["b"]; !ok {
+		t.Errorf("bucket of b dropped")
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"analytics/internal/metrics"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AnomalyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAnomalyRepository(db *pgxpool.Pool) *AnomalyRepository {
+	return &AnomalyRepository{db: db}
+}
+
+// MarkAnomalyDetected records that the transaction was reported as an
+// anomaly, and reports whether it was not recorded yet. Concurrent detectors
+// rely on this to publish it only once.
+func (r *AnomalyRepository) MarkAnomalyDetected(ctx context.Context, transactionID int) (bool, error) {
+	defer metrics.ObserveDBQuery("mark_anomaly_detected")()
+
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO anomaly_alerts (transaction_id)
+		VALUES ($1)
+		ON CONFLICT DO NOTHING
+	`, transactionID)
+	if err != nil {
+		return false, fmt.Errorf("insert failed: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
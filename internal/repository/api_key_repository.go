@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			user_id,
+			daily_token_quota,
+			monthly_token_quota,
+			daily_cost_quota,
+			monthly_cost_quota
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.UserID,
+		key.Quota.DailyTokens,
+		key.Quota.MonthlyTokens,
+		key.Quota.DailyCost,
+		key.Quota.MonthlyCost,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Insert failed", "component", "APIKeyRepository.CreateAPIKey", "error", err)
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+// GetActiveAPIKeyByHash returns the non-revoked key with the given hash and
+// records the lookup as its last use. last_used_at is only written when it is
+// over a minute old, so busy keys do not update their row on every request;
+// the returned key has the value from before the lookup.
+func (r *APIKeyRepository) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	defer metrics.ObserveDBQuery("get_api_key")()
+
+	var key domain.APIKey
+	err := r.db.QueryRow(ctx, `
+		WITH key AS (
+			SELECT id, name, prefix, key_hash, scopes, user_id, created_at, last_used_at, revoked_at,
+				daily_token_quota, monthly_token_quota, daily_cost_quota, monthly_cost_quota
+			FROM api_keys
+			WHERE key_hash = $1 AND revoked_at IS NULL
+		), touched AS (
+			UPDATE api_keys
+			SET last_used_at = CURRENT_TIMESTAMP
+			WHERE id = (SELECT id FROM key)
+				AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+		)
+		SELECT * FROM key
+	`, keyHash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.UserID,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.Quota.DailyTokens,
+		&key.Quota.MonthlyTokens,
+		&key.Quota.DailyCost,
+		&key.Quota.MonthlyCost,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup failed: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) GetAllAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			id, name, prefix, key_hash, scopes, user_id, created_at, last_used_at, revoked_at,
+			daily_token_quota, monthly_token_quota, daily_cost_quota, monthly_cost_quota
+		FROM api_keys
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		var key domain.APIKey
+		err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&key.Scopes,
+			&key.UserID,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.Quota.DailyTokens,
+			&key.Quota.MonthlyTokens,
+			&key.Quota.DailyCost,
+			&key.Quota.MonthlyCost,
+		)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("revoke failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrBudgetNotFound = errors.New("budget not found")
+
+type BudgetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBudgetRepository(db *pgxpool.Pool) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// SetBudget stores the budget of a category, replacing its amount when it
+// already has one. A budget whose amount changes may be reported crossed
+// again in the months it already was.
+func (r *BudgetRepository) SetBudget(ctx context.Context, budget *domain.Budget) error {
+	defer metrics.ObserveDBQuery("set_budget")()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var previous *float64
+		err := tx.QueryRow(ctx, `SELECT amount FROM budgets WHERE category_id = $1 FOR UPDATE`, budget.CategoryID).Scan(&previous)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO budgets (category_id, amount)
+			VALUES ($1, $2)
+			ON CONFLICT (category_id) DO UPDATE
+			SET amount = EXCLUDED.amount,
+				updated_at = CURRENT_TIMESTAMP
+			RETURNING id, created_at, updated_at
+		`, budget.CategoryID, budget.Amount).Scan(&budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if previous != nil && *previous != budget.Amount {
+			_, err = tx.Exec(ctx, `DELETE FROM budget_alerts WHERE budget_id = $1`, budget.ID)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+	return nil
+}
+
+func (r *BudgetRepository) GetAllBudgets(ctx context.Context) ([]domain.Budget, error) {
+	defer metrics.ObserveDBQuery("get_budgets")()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, category_id, amount, created_at, updated_at
+		FROM budgets
+		ORDER BY category_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Budget])
+}
+
+// DeleteBudget removes the budget of a category.
+func (r *BudgetRepository) DeleteBudget(ctx context.Context, categoryID int) error {
+	defer metrics.ObserveDBQuery("delete_budget")()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM budgets WHERE category_id = $1`, categoryID)
+	if err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// MarkBudgetExceeded records that the budget was crossed in the UTC month
+// starting at month, and reports whether it was not recorded yet. Concurrent
+// checkers rely on this to publish it only once.
+func (r *BudgetRepository) MarkBudgetExceeded(ctx context.Context, budgetID int, month time.Time) (bool, error) {
+	defer metrics.ObserveDBQuery("mark_budget_exceeded")()
+
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO budget_alerts (budget_id, month)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, budgetID, month)
+	if err != nil {
+		return false, fmt.Errorf("insert failed: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -1,24 +1,44 @@
 package repository
 
 import (
+	"analytics/internal/changes"
 	"analytics/internal/domain"
+	"analytics/internal/metrics"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrCategoryNotFound = errors.New("category not found")
+
 type CategoryRepository struct {
-	db *pgxpool.Pool
+	db         *pgxpool.Pool
+	categories *changes.Cache[[]domain.Category]
 }
 
-func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepository {
-	return &CategoryRepository{db: db}
+func NewCategoryRepository(db *pgxpool.Pool, listener *changes.Listener) *CategoryRepository {
+	return &CategoryRepository{
+		db:         db,
+		categories: changes.NewCache[[]domain.Category](listener, changes.Categories),
+	}
 }
 
+// GetAllCategories returns every category, cached until categories change.
 func (r *CategoryRepository) GetAllCategories(ctx context.Context) ([]domain.Category, error) {
-	rows, err := r.db.Query(ctx, `
+	categories, err := r.categories.Get(ctx, r.getAllCategories)
+	return slices.Clone(categories), err
+}
+
+func (r *CategoryRepository) getAllCategories(ctx context.Context) ([]domain.Category, error) {
+	defer metrics.ObserveDBQuery("get_categories")()
+
+	return r.queryCategories(ctx, `
 		SELECT 
 			id,
+			parent_id,
 			updated_at,
 			created_at,
 			deleted_at,
@@ -27,7 +47,44 @@ func (r *CategoryRepository) GetAllCategories(ctx context.Context) ([]domain.Cat
 			color
 		FROM categories
 	`)
+}
+
+// GetCategoriesByIDs returns the categories with the given ids in a single
+// query, in no particular order. Unknown ids are left out.
+func (r *CategoryRepository) GetCategoriesByIDs(ctx context.Context, ids []int) ([]domain.Category, error) {
+	defer metrics.ObserveDBQuery("get_categories_by_ids")()
+
+	return r.queryCategories(ctx, `
+		SELECT
+			id,
+			parent_id,
+			updated_at,
+			created_at,
+			deleted_at,
+			name,
+			description,
+			color
+		FROM categories
+		WHERE id = ANY($1)
+	`, ids)
+}
+
+// SetCategoryParent sets the parent of a category, nil making it top level.
+func (r *CategoryRepository) SetCategoryParent(ctx context.Context, id int, parentID *int) error {
+	defer metrics.ObserveDBQuery("set_category_parent")()
+
+	tag, err := r.db.Exec(ctx, `UPDATE categories SET parent_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, parentID)
+	if err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
 
+func (r *CategoryRepository) queryCategories(ctx context.Context, sql string, args ...any) ([]domain.Category, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +95,7 @@ func (r *CategoryRepository) GetAllCategories(ctx context.Context) ([]domain.Cat
 		var category domain.Category
 		err := rows.Scan(
 			&category.ID,
+			&category.ParentID,
 			&category.UpdatedAt,
 			&category.CreatedAt,
 			&category.DeletedAt,
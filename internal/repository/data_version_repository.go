@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"analytics/internal/changes"
+	"analytics/internal/metrics"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dataTables are the tables the data version covers
+var dataTables = []changes.Table{changes.Transactions, changes.Categories, changes.Tags, changes.TransactionTags, changes.MerchantRules}
+
+type DataVersionRepository struct {
+	db       *pgxpool.Pool
+	listener *changes.Listener
+	version  *changes.Cache[string]
+	// started tells apart the change counts of successive processes, which
+	// all start from zero.
+	started int64
+}
+
+func NewDataVersionRepository(db *pgxpool.Pool, listener *changes.Listener) *DataVersionRepository {
+	return &DataVersionRepository{
+		db:       db,
+		listener: listener,
+		version:  changes.NewCache[string](listener, dataTables...),
+		started:  time.Now().UnixNano(),
+	}
+}
+
+// GetDataVersion returns a fingerprint of the transactions, categories, tags
+// and merchant rules that changes whenever they do. While change
+// notifications are received it starts with the number of changes seen, which
+// moves with every write. The rest moves with most writes on its own, for when
+// notifications are not: the latest updated_at or created_at of each table
+// moves with inserts and with updates that set it, and their row counts move
+// with deletes.
+func (r *DataVersionRepository) GetDataVersion(ctx context.Context) (string, error) {
+	return r.version.Get(ctx, r.loadDataVersion)
+}
+
+func (r *DataVersionRepository) loadDataVersion(ctx context.Context) (string, error) {
+	// Counted before querying, so a change made in between moves the next
+	// version rather than being missed.
+	changeCount, listening := r.listener.Version(dataTables...)
+
+	defer metrics.ObserveDBQuery("get_data_version")()
+
+	var version string
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			(SELECT format('%s/%s', MAX(updated_at), COUNT(*)) FROM transactions)
+			|| ';' ||
+			(SELECT format('%s/%s/%s', MAX(updated_at), MAX(deleted_at), COUNT(*)) FROM categories)
+			|| ';' ||
+			(SELECT format('%s/%s', MAX(created_at), COUNT(*)) FROM tags)
+			|| ';' ||
+			(SELECT format('%s/%s', MAX(created_at), COUNT(*)) FROM transaction_tags)
+			|| ';' ||
+			(SELECT format('%s/%s', MAX(created_at), COUNT(*)) FROM merchant_rules)
+	`).Scan(&version)
+	if err != nil {
+		slog.ErrorContext(ctx, "Query failed", "component", "DataVersionRepository.GetDataVersion", "error", err)
+		return "", fmt.Errorf("query failed: %w", err)
+	}
+	if listening {
+		version = fmt.Sprintf("%d.%d;%s", r.started, changeCount, version)
+	}
+	return version, nil
+}
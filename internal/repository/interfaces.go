@@ -3,12 +3,68 @@ package repository
 import (
 	"analytics/internal/domain"
 	"context"
+	"time"
 )
 
+// TransactionFilter narrows the transactions returned by a repository.
+// Nil fields are not applied. CategoryIDs also matches their subcategories
+// and Tags matches transactions with any of the tag names. From and To bound
+// the transaction date, from inclusive and to exclusive.
+type TransactionFilter struct {
+	CreatedByID *int
+	Type        *domain.Type
+	Subtype     *string
+	CategoryIDs []int
+	Tags        []string
+	From        *time.Time
+	To          *time.Time
+}
+
 type TransactionRepositoryInterface interface {
 	GetAllTransactions(ctx context.Context) ([]domain.Transaction, error)
+	GetTransactions(ctx context.Context, filter TransactionFilter) ([]domain.Transaction, error)
+	GetMonthlyTotals(ctx context.Context, filter TransactionFilter) ([]domain.MonthlyTotal, error)
 }
 
 type CategoryRepositoryInterface interface {
 	GetAllCategories(ctx context.Context) ([]domain.Category, error)
+	SetCategoryParent(ctx context.Context, id int, parentID *int) error
+}
+
+type ReportRepositoryInterface interface {
+	CreateReport(ctx context.Context, report *domain.Report) (bool, error)
+	SaveReport(ctx context.Context, report *domain.Report) error
+	HasReport(ctx context.Context, period domain.ReportPeriod, start time.Time) (bool, error)
+	GetReport(ctx context.Context, id int) (*domain.Report, error)
+	GetReports(ctx context.Context, period *domain.ReportPeriod, limit int) ([]domain.Report, error)
+}
+
+type WebhookRepositoryInterface interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) error
+	GetWebhook(ctx context.Context, id int) (*domain.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	CreateDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error)
+}
+
+type BudgetRepositoryInterface interface {
+	SetBudget(ctx context.Context, budget *domain.Budget) error
+	GetAllBudgets(ctx context.Context) ([]domain.Budget, error)
+	DeleteBudget(ctx context.Context, categoryID int) error
+	MarkBudgetExceeded(ctx context.Context, budgetID int, month time.Time) (bool, error)
+}
+
+type AnomalyRepositoryInterface interface {
+	MarkAnomalyDetected(ctx context.Context, transactionID int) (bool, error)
+}
+
+type LLMUsageRepositoryInterface interface {
+	CreateUsage(ctx context.Context, usage *domain.LLMUsage) error
+	ReserveUsage(ctx context.Context, clientID string, since []time.Time, reserved UsageTotal, expiresAt time.Time, check func([]UsageTotal) error) (int, error)
+	ReleaseReservation(ctx context.Context, id int) error
+	GetAverageUsage(ctx context.Context, clientID string, since time.Time) (UsageTotal, error)
+	GetUsageAggregates(ctx context.Context, groupBy string, from time.Time, to time.Time) ([]LLMUsageAggregate, error)
 }
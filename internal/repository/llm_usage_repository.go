@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LLMUsageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLLMUsageRepository(db *pgxpool.Pool) *LLMUsageRepository {
+	return &LLMUsageRepository{db: db}
+}
+
+func (r *LLMUsageRepository) CreateUsage(ctx context.Context, usage *domain.LLMUsage) error {
+	defer metrics.ObserveDBQuery("create_llm_usage")()
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO llm_usage (
+			client_id,
+			api_key_id,
+			endpoint,
+			model,
+			prompt_tokens,
+			completion_tokens,
+			cost,
+			latency_ms
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`,
+		usage.ClientID,
+		usage.APIKeyID,
+		usage.Endpoint,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.Cost,
+		usage.LatencyMs,
+	).Scan(&usage.ID, &usage.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Insert failed", "component", "LLMUsageRepository.CreateUsage", "error", err)
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+// usageReservationLock namespaces the advisory locks that serialize the
+// reservations of each client.
+const usageReservationLock = 28
+
+// UsageTotal is the tokens and cost a client used or holds since a time.
+type UsageTotal struct {
+	Tokens int64
+	Cost   float64
+}
+
+// ReserveUsage holds tokens and cost for clientID until expiresAt. It first
+// passes check the usage since each of since, counting the unexpired
+// reservations, and reserves nothing if check returns an error. Reservations
+// of a client are serialized, so concurrent ones always see each other.
+func (r *LLMUsageRepository) ReserveUsage(ctx context.Context, clientID string, since []time.Time, reserved UsageTotal, expiresAt time.Time, check func([]UsageTotal) error) (int, error) {
+	defer metrics.ObserveDBQuery("reserve_llm_usage")()
+
+	var id int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, usageReservationLock, clientID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM llm_usage_reservations WHERE client_id = $1 AND expires_at <= CURRENT_TIMESTAMP`, clientID)
+		if err != nil {
+			return err
+		}
+
+		totals := make([]UsageTotal, len(since))
+		for i, start := range since {
+			err := tx.QueryRow(ctx, `
+				SELECT
+					COALESCE(SUM(tokens), 0)::bigint,
+					COALESCE(SUM(cost), 0)::float8
+				FROM (
+					SELECT prompt_tokens + completion_tokens AS tokens, cost
+					FROM llm_usage
+					WHERE client_id = $1 AND created_at >= $2
+					UNION ALL
+					SELECT tokens, cost
+					FROM llm_usage_reservations
+					WHERE client_id = $1
+				) held
+			`, clientID, start).Scan(&totals[i].Tokens, &totals[i].Cost)
+			if err != nil {
+				return err
+			}
+		}
+		if err := check(totals); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, `
+			INSERT INTO llm_usage_reservations (client_id, tokens, cost, expires_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, clientID, reserved.Tokens, reserved.Cost, expiresAt).Scan(&id)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("reservation failed: %w", err)
+	}
+	return id, nil
+}
+
+// ReleaseReservation deletes a reservation made by ReserveUsage.
+func (r *LLMUsageRepository) ReleaseReservation(ctx context.Context, id int) error {
+	defer metrics.ObserveDBQuery("release_llm_usage_reservation")()
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM llm_usage_reservations WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+// GetAverageUsage returns the average tokens and cost of the completions a
+// client made since the given time, which are zero without any.
+func (r *LLMUsageRepository) GetAverageUsage(ctx context.Context, clientID string, since time.Time) (UsageTotal, error) {
+	defer metrics.ObserveDBQuery("get_llm_usage_average")()
+
+	var average UsageTotal
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			COALESCE(AVG(prompt_tokens + completion_tokens), 0)::bigint,
+			COALESCE(AVG(cost), 0)::float8
+		FROM llm_usage
+		WHERE client_id = $1 AND created_at >= $2
+	`, clientID, since).Scan(&average.Tokens, &average.Cost)
+	if err != nil {
+		return UsageTotal{}, fmt.Errorf("usage lookup failed: %w", err)
+	}
+	return average, nil
+}
+
+// LLMUsageAggregate sums the calls made within one group of a usage report
+type LLMUsageAggregate struct {
+	Group            string
+	Calls            int64
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+	AvgLatencyMs     float64
+}
+
+// GetUsageAggregates groups the calls made in [from, to) by groupBy, which must
+// be one of the columns or expressions in usageGroupings.
+func (r *LLMUsageRepository) GetUsageAggregates(ctx context.Context, groupBy string, from time.Time, to time.Time) ([]LLMUsageAggregate, error) {
+	expression, ok := usageGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown usage grouping %q", groupBy)
+	}
+
+	defer metrics.ObserveDBQuery("get_llm_usage_aggregates")()
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT
+			%s AS grp,
+			COUNT(*),
+			COALESCE(SUM(prompt_tokens), 0)::bigint,
+			COALESCE(SUM(completion_tokens), 0)::bigint,
+			COALESCE(SUM(cost), 0)::float8,
+			COALESCE(AVG(latency_ms), 0)::float8
+		FROM llm_usage
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY grp
+		ORDER BY grp
+	`, expression), from, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "Query failed", "component", "LLMUsageRepository.GetUsageAggregates", "error", err)
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var aggregates []LLMUsageAggregate
+	for rows.Next() {
+		var aggregate LLMUsageAggregate
+		err := rows.Scan(
+			&aggregate.Group,
+			&aggregate.Calls,
+			&aggregate.PromptTokens,
+			&aggregate.CompletionTokens,
+			&aggregate.Cost,
+			&aggregate.AvgLatencyMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return aggregates, nil
+}
+
+var usageGroupings = map[string]string{
+	"day":      "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+	"endpoint": "endpoint",
+	"model":    "model",
+}
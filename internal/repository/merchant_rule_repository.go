@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrMerchantRuleNotFound = errors.New("merchant rule not found")
+
+type MerchantRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMerchantRuleRepository(db *pgxpool.Pool) *MerchantRuleRepository {
+	return &MerchantRuleRepository{db: db}
+}
+
+func (r *MerchantRuleRepository) CreateMerchantRule(ctx context.Context, rule *domain.MerchantRule) error {
+	defer metrics.ObserveDBQuery("create_merchant_rule")()
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO merchant_rules (kind, match, merchant)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, rule.Kind, rule.Match, rule.Merchant).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+// GetAllMerchantRules returns every rule in the order they were created,
+// which is the order they are tried in.
+func (r *MerchantRuleRepository) GetAllMerchantRules(ctx context.Context) ([]domain.MerchantRule, error) {
+	defer metrics.ObserveDBQuery("get_merchant_rules")()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, kind, match, merchant, created_at
+		FROM merchant_rules
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.MerchantRule])
+}
+
+func (r *MerchantRuleRepository) DeleteMerchantRule(ctx context.Context, id int) error {
+	defer metrics.ObserveDBQuery("delete_merchant_rule")()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM merchant_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMerchantRuleNotFound
+	}
+	return nil
+}
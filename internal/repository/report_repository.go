@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrReportNotFound = errors.New("report not found")
+
+type ReportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// CreateReport stores a report unless its period already has one, and
+// reports whether it did. Concurrent schedulers rely on this to deliver a
+// report only once.
+func (r *ReportRepository) CreateReport(ctx context.Context, report *domain.Report) (bool, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO reports (period, period_start, period_end, summary, narrative)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (period, period_start) DO NOTHING
+		RETURNING id, created_at
+	`, report.Period, report.PeriodStart, report.PeriodEnd, report.Summary, report.Narrative).Scan(&report.ID, &report.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("insert failed: %w", err)
+	}
+	return true, nil
+}
+
+// SaveReport stores a report, replacing the one of the same period if any.
+func (r *ReportRepository) SaveReport(ctx context.Context, report *domain.Report) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO reports (period, period_start, period_end, summary, narrative)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (period, period_start) DO UPDATE
+		SET period_end = EXCLUDED.period_end,
+			summary = EXCLUDED.summary,
+			narrative = EXCLUDED.narrative,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at
+	`, report.Period, report.PeriodStart, report.PeriodEnd, report.Summary, report.Narrative).Scan(&report.ID, &report.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+	return nil
+}
+
+// HasReport reports whether the period starting at start already has a report.
+func (r *ReportRepository) HasReport(ctx context.Context, period domain.ReportPeriod, start time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM reports WHERE period = $1 AND period_start = $2)
+	`, period, start).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("lookup failed: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *ReportRepository) GetReport(ctx context.Context, id int) (*domain.Report, error) {
+	var report domain.Report
+	err := r.db.QueryRow(ctx, `
+		SELECT id, period, period_start, period_end, summary, narrative, created_at
+		FROM reports
+		WHERE id = $1
+	`, id).Scan(
+		&report.ID,
+		&report.Period,
+		&report.PeriodStart,
+		&report.PeriodEnd,
+		&report.Summary,
+		&report.Narrative,
+		&report.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup failed: %w", err)
+	}
+	return &report, nil
+}
+
+// GetReports returns the latest reports, of one period when period is not
+// nil, newest first.
+func (r *ReportRepository) GetReports(ctx context.Context, period *domain.ReportPeriod, limit int) ([]domain.Report, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, period, period_start, period_end, summary, narrative, created_at
+		FROM reports
+		WHERE ($1::text IS NULL OR period = $1)
+		ORDER BY period_start DESC, period
+		LIMIT $2
+	`, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []domain.Report
+	for rows.Next() {
+		var report domain.Report
+		err := rows.Scan(
+			&report.ID,
+			&report.Period,
+			&report.PeriodStart,
+			&report.PeriodEnd,
+			&report.Summary,
+			&report.Narrative,
+			&report.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SchemaRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSchemaRepository(db *pgxpool.Pool) *SchemaRepository {
+	return &SchemaRepository{db: db}
+}
+
+func (r *SchemaRepository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+type TagRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTagRepository(db *pgxpool.Pool) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetAllTags returns every tag, ordered by name.
+func (r *TagRepository) GetAllTags(ctx context.Context) ([]domain.Tag, error) {
+	defer metrics.ObserveDBQuery("get_tags")()
+
+	rows, err := r.db.Query(ctx, `SELECT id, name, created_at FROM tags ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Tag])
+}
+
+// SetTransactionTags replaces the tags of a transaction with the named ones,
+// creating those that do not exist yet.
+func (r *TagRepository) SetTransactionTags(ctx context.Context, transactionID int, names []string) error {
+	defer metrics.ObserveDBQuery("set_transaction_tags")()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM transactions WHERE id = $1)`, transactionID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrTransactionNotFound
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, names); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM transaction_tags
+			WHERE transaction_id = $1 AND tag_id NOT IN (SELECT id FROM tags WHERE name = ANY($2))
+		`, transactionID, names); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO transaction_tags (transaction_id, tag_id)
+			SELECT $1, id FROM tags WHERE name = ANY($2)
+			ON CONFLICT DO NOTHING
+		`, transactionID, names)
+		return err
+	})
+	if errors.Is(err, ErrTransactionNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set tags: %w", err)
+	}
+	return nil
+}
@@ -1,39 +1,123 @@
 package repository
 
 import (
+	"analytics/internal/changes"
 	"analytics/internal/domain"
+	"analytics/internal/metrics"
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// monthlyTotalsLock is the advisory lock held while refreshing the monthly
+// totals. It is next to the migrations lock, away from the per-client locks
+// of the LLM usage reservations.
+const monthlyTotalsLock = 4_716_255_191
+
 type TransactionRepository struct {
 	db *pgxpool.Pool
+	// monthlyTotals is only valid until transactions change, after which the
+	// monthly totals may need a refresh.
+	monthlyTotals *changes.Cache[struct{}]
 }
 
-func NewTransactionRepository(db *pgxpool.Pool) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *pgxpool.Pool, listener *changes.Listener) *TransactionRepository {
+	return &TransactionRepository{
+		db:            db,
+		monthlyTotals: changes.NewCache[struct{}](listener, changes.Transactions),
+	}
 }
 
 func (r *TransactionRepository) GetAllTransactions(ctx context.Context) ([]domain.Transaction, error) {
-	rows, err := r.db.Query(ctx, `
+	return r.GetTransactions(ctx, TransactionFilter{})
+}
+
+func (r *TransactionRepository) GetTransactions(ctx context.Context, filter TransactionFilter) ([]domain.Transaction, error) {
+	defer metrics.ObserveDBQuery("get_transactions")()
+
+	return r.queryTransactions(ctx, "TransactionRepository.GetTransactions", `
+		SELECT
+			id,
+			category_id,
+			created_by_id,
+			amount,
+			type,
+			subtype,
+			updated_at,
+			date,
+			created_at,
+			start_date,
+			end_date,
+			description,
+			ARRAY(
+				SELECT t.name FROM transaction_tags tt JOIN tags t ON t.id = tt.tag_id
+				WHERE tt.transaction_id = transactions.id
+				ORDER BY t.name
+			) AS tags
+		FROM transactions
+		WHERE ($1::int IS NULL OR created_by_id = $1)
+			AND ($2::text IS NULL OR type::text = $2)
+			AND ($3::int[] IS NULL OR category_id IN (SELECT category_subtree($3)))
+			AND ($4::timestamptz IS NULL OR date >= $4)
+			AND ($5::timestamptz IS NULL OR date < $5)
+			AND ($6::text[] IS NULL OR id IN (
+				SELECT tt.transaction_id FROM transaction_tags tt JOIN tags t ON t.id = tt.tag_id
+				WHERE t.name = ANY($6)
+			))
+			AND ($7::text IS NULL OR subtype = $7)
+	`, filter.CreatedByID, filter.Type, filter.CategoryIDs, filter.From, filter.To, filter.Tags, filter.Subtype)
+}
+
+// GetLatestTransactions returns up to limit transactions matching filter after
+// skipping offset of them, the most recent date first. Transactions without a
+// date come last.
+func (r *TransactionRepository) GetLatestTransactions(ctx context.Context, filter TransactionFilter, limit int, offset int) ([]domain.Transaction, error) {
+	defer metrics.ObserveDBQuery("get_latest_transactions")()
+
+	return r.queryTransactions(ctx, "TransactionRepository.GetLatestTransactions", `
 		SELECT
 			id,
 			category_id,
+			created_by_id,
 			amount,
 			type,
+			subtype,
 			updated_at,
 			date,
 			created_at,
-		    start_date,
-		    end_date,
-			description
+			start_date,
+			end_date,
+			description,
+			ARRAY(
+				SELECT t.name FROM transaction_tags tt JOIN tags t ON t.id = tt.tag_id
+				WHERE tt.transaction_id = transactions.id
+				ORDER BY t.name
+			) AS tags
 		FROM transactions
-	`)
+		WHERE ($1::int IS NULL OR created_by_id = $1)
+			AND ($2::text IS NULL OR type::text = $2)
+			AND ($3::int[] IS NULL OR category_id IN (SELECT category_subtree($3)))
+			AND ($4::timestamptz IS NULL OR date >= $4)
+			AND ($5::timestamptz IS NULL OR date < $5)
+			AND ($6::text[] IS NULL OR id IN (
+				SELECT tt.transaction_id FROM transaction_tags tt JOIN tags t ON t.id = tt.tag_id
+				WHERE t.name = ANY($6)
+			))
+			AND ($7::text IS NULL OR subtype = $7)
+		ORDER BY date DESC NULLS LAST, id DESC
+		LIMIT $8 OFFSET $9
+	`, filter.CreatedByID, filter.Type, filter.CategoryIDs, filter.From, filter.To, filter.Tags, filter.Subtype, limit, offset)
+}
+
+func (r *TransactionRepository) queryTransactions(ctx context.Context, component string, query string, args ...any) ([]domain.Transaction, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		log.Printf("[TransactionRepository.GetAllTransactions] ERROR: Query failed: %v", err)
+		slog.ErrorContext(ctx, "Query failed", "component", component, "error", err)
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
@@ -45,17 +129,20 @@ func (r *TransactionRepository) GetAllTransactions(ctx context.Context) ([]domai
 		err := rows.Scan(
 			&transaction.ID,
 			&transaction.CategoryID,
+			&transaction.CreatedById,
 			&transaction.Amount,
 			&transaction.Type,
+			&transaction.Subtype,
 			&transaction.UpdatedAt,
 			&transaction.Date,
 			&transaction.CreatedAt,
 			&transaction.StartDate,
 			&transaction.EndDate,
 			&transaction.Description,
+			&transaction.Tags,
 		)
 		if err != nil {
-			log.Printf("[TransactionRepository.GetAllTransactions] ERROR: Failed to scan row %d: %v", rowCount, err)
+			slog.ErrorContext(ctx, "Failed to scan row", "component", component, "row", rowCount, "error", err)
 			return nil, fmt.Errorf("failed to scan row %d: %w", rowCount, err)
 		}
 		transactions = append(transactions, transaction)
@@ -63,9 +150,129 @@ func (r *TransactionRepository) GetAllTransactions(ctx context.Context) ([]domai
 	}
 
 	if err := rows.Err(); err != nil {
-		log.Printf("[TransactionRepository.GetAllTransactions] ERROR: Row iteration error: %v", err)
+		slog.ErrorContext(ctx, "Row iteration error", "component", component, "error", err)
 		return nil, fmt.Errorf("row iteration error: %w", err)
 	}
 
 	return transactions, nil
 }
+
+// GetMonthlyTotals returns the monthly totals of the transactions matching
+// filter, after recomputing the months written to since the last refresh. From
+// and To are truncated to the start of their UTC month, so callers wanting
+// exact results pass month starts. The totals do not record tags, so filter
+// must not have any.
+func (r *TransactionRepository) GetMonthlyTotals(ctx context.Context, filter TransactionFilter) ([]domain.MonthlyTotal, error) {
+	if filter.Tags != nil {
+		return nil, errors.New("monthly totals cannot be filtered by tag")
+	}
+
+	_, err := r.monthlyTotals.Get(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.refreshMonthlyTotals(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer metrics.ObserveDBQuery("get_monthly_totals")()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT month, category_id, type, subtype, created_by_id, amount, count
+		FROM transaction_monthly_totals
+		WHERE ($1::int IS NULL OR created_by_id = $1)
+			AND ($2::text IS NULL OR type::text = $2)
+			AND ($3::int[] IS NULL OR category_id IN (SELECT category_subtree($3)))
+			AND ($4::timestamptz IS NULL OR month >= date_trunc('month', $4 AT TIME ZONE 'UTC'))
+			AND ($5::timestamptz IS NULL OR month < date_trunc('month', $5 AT TIME ZONE 'UTC'))
+			AND ($6::text IS NULL OR subtype = $6)
+		ORDER BY month
+	`, filter.CreatedByID, filter.Type, filter.CategoryIDs, filter.From, filter.To, filter.Subtype)
+	if err != nil {
+		slog.ErrorContext(ctx, "Query failed", "component", "TransactionRepository.GetMonthlyTotals", "error", err)
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.MonthlyTotal
+	for rows.Next() {
+		var total domain.MonthlyTotal
+		err := rows.Scan(
+			&total.Month,
+			&total.CategoryID,
+			&total.Type,
+			&total.Subtype,
+			&total.UserID,
+			&total.Amount,
+			&total.Count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return totals, nil
+}
+
+// refreshMonthlyTotals recomputes the months the transactions trigger marked
+// stale. Each writing transaction adds its own marks, so only the marks of
+// committed writes are visible and deleted here, and the totals are computed
+// in a later statement, so they include every write whose mark was deleted.
+// Marks of writes still in flight are left for the next refresh. Writers never
+// wait on a refresh. Refreshes hold monthlyTotalsLock, so they run one at a
+// time and never both clear and fill a month, and give up rather than hold up
+// reads when waiting takes too long.
+func (r *TransactionRepository) refreshMonthlyTotals(ctx context.Context) error {
+	defer metrics.ObserveDBQuery("refresh_monthly_totals")()
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SET LOCAL lock_timeout = '5s'`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `SET LOCAL statement_timeout = '30s'`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, monthlyTotalsLock); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `
+			WITH marks AS (
+				DELETE FROM transaction_monthly_totals_stale RETURNING month
+			)
+			SELECT DISTINCT month FROM marks
+		`)
+		if err != nil {
+			return err
+		}
+		months, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+		if err != nil || len(months) == 0 {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM transaction_monthly_totals WHERE month = ANY($1)`, months); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO transaction_monthly_totals (month, category_id, type, subtype, created_by_id, amount, count)
+			SELECT date_trunc('month', date AT TIME ZONE 'UTC')::date, category_id, type, NULLIF(subtype, ''), created_by_id, SUM(amount), COUNT(*)
+			FROM transactions
+			WHERE date >= (SELECT MIN(m) FROM unnest($1::date[]) m)::timestamp AT TIME ZONE 'UTC'
+				AND date < ((SELECT MAX(m) FROM unnest($1::date[]) m) + INTERVAL '1 month') AT TIME ZONE 'UTC'
+				AND date_trunc('month', date AT TIME ZONE 'UTC')::date = ANY($1)
+			GROUP BY 1, 2, 3, 4, 5
+			ON CONFLICT (month, (COALESCE(category_id, 0)), type, (COALESCE(subtype, '')), created_by_id)
+			DO UPDATE SET amount = EXCLUDED.amount, count = EXCLUDED.count
+		`, months)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Refresh failed", "component", "TransactionRepository.refreshMonthlyTotals", "error", err)
+		return fmt.Errorf("failed to refresh monthly totals: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"analytics/internal/migrations"
+	"context"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to TEST_DATABASE_URL, a disposable database the tests
+// migrate and write to, and skips the test when it is not set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrations.NewMigrator(pool)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pool
+}
+
+func TestConcurrentMonthlyTotalsRefreshes(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	r := NewTransactionRepository(pool, nil)
+
+	// A user of its own keeps the check apart from whatever else is stored.
+	userID := 1_000_000 + rand.IntN(1_000_000)
+	month := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(ctx, `DELETE FROM transactions WHERE created_by_id = $1`, userID)
+		_ = r.refreshMonthlyTotals(ctx)
+	})
+
+	// No subtype and an empty one are totalled together.
+	empty, rent := "", "rent"
+	subtypes := []*string{nil, &empty, &rent}
+
+	const rounds, refreshes = 20, 4
+	for round := range rounds {
+		_, err := pool.Exec(ctx, `
+			INSERT INTO transactions (category_id, amount, type, subtype, date, created_by_id)
+			VALUES (NULL, $1, 'expense', $2, $3, $4)
+		`, 10+round, subtypes[round%len(subtypes)], month.AddDate(0, 0, round), userID)
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+
+		errs := make(chan error, refreshes)
+		var wg sync.WaitGroup
+		for range refreshes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- r.refreshMonthlyTotals(ctx)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("round %d: %v", round, err)
+			}
+		}
+
+		var wantAmount, gotAmount float64
+		var wantCount, gotCount int
+		err = pool.QueryRow(ctx, `
+			SELECT COALESCE(SUM(amount), 0)::float8, COUNT(*)::int
+			FROM transactions WHERE created_by_id = $1
+		`, userID).Scan(&wantAmount, &wantCount)
+		if err != nil {
+			t.Fatalf("sum transactions: %v", err)
+		}
+		err = pool.QueryRow(ctx, `
+			SELECT COALESCE(SUM(amount), 0)::float8, COALESCE(SUM(count), 0)::int
+			FROM transaction_monthly_totals WHERE created_by_id = $1
+		`, userID).Scan(&gotAmount, &gotCount)
+		if err != nil {
+			t.Fatalf("sum totals: %v", err)
+		}
+		if gotAmount != wantAmount || gotCount != wantCount {
+			t.Fatalf("round %d: totals have %.2f over %d transactions, want %.2f over %d", round, gotAmount, gotCount, wantAmount, wantCount)
+		}
+	}
+}
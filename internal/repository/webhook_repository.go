@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// DueDelivery is a delivery claimed for an attempt, with where to send it
+type DueDelivery struct {
+	Delivery domain.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO webhooks (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, webhook.URL, webhook.Secret, webhook.Events).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id int) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := r.db.QueryRow(ctx, `
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		WHERE id = $1
+	`, id).Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &webhook.Events, &webhook.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup failed: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := rows.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &webhook.Events, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook along with its delivery log.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries queues deliveries, due immediately.
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	defer metrics.ObserveDBQuery("create_webhook_deliveries")()
+
+	batch := &pgx.Batch{}
+	for _, delivery := range deliveries {
+		batch.Queue(`
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, status, attempts, next_attempt_at, created_at
+		`, delivery.WebhookID, delivery.EventID, delivery.EventType, string(delivery.Payload)).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&delivery.ID, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.CreatedAt)
+		})
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries that are due and
+// pushes their next attempt lease into the future, so that other instances
+// skip them while they are being sent and they are retried if this one dies.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueDelivery, error) {
+	defer metrics.ObserveDBQuery("claim_webhook_deliveries")()
+
+	rows, err := r.db.Query(ctx, `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at, w.url, w.secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim failed: %w", err)
+	}
+	defer rows.Close()
+
+	var due []DueDelivery
+	for rows.Next() {
+		var d DueDelivery
+		var payload string
+		err := rows.Scan(
+			&d.Delivery.ID,
+			&d.Delivery.WebhookID,
+			&d.Delivery.EventID,
+			&d.Delivery.EventType,
+			&payload,
+			&d.Delivery.Status,
+			&d.Delivery.Attempts,
+			&d.Delivery.NextAttemptAt,
+			&d.Delivery.LastStatusCode,
+			&d.Delivery.LastError,
+			&d.Delivery.CreatedAt,
+			&d.Delivery.DeliveredAt,
+			&d.URL,
+			&d.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.Delivery.Payload = json.RawMessage(payload)
+		due = append(due, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
+
+// UpdateDelivery records the outcome of an attempt.
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	defer metrics.ObserveDBQuery("update_webhook_delivery")()
+
+	_, err := r.db.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			last_status_code = $5,
+			last_error = $6,
+			delivered_at = $7
+		WHERE id = $1
+	`,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		var payload string
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,98 @@
+// Package responsecache keeps encoded responses in memory, each for a limited
+// time and all of them within a total size.
+package responsecache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	ETag        string
+	ContentType string
+	Body        []byte
+}
+
+type item struct {
+	key     string
+	entry   Entry
+	size    int
+	expires time.Time
+}
+
+// Cache evicts the least recently used entries once it holds more than its
+// size bound, and drops entries older than its TTL. It is safe for concurrent
+// use.
+type Cache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxBytes int
+	size     int
+	// order holds the items, the most recently used first.
+	order *list.List
+	items map[string]*list.Element
+}
+
+// New keeps each entry for ttl, and at most maxBytes of keys and responses in
+// total.
+func New(ttl time.Duration, maxBytes int) *Cache {
+	return &Cache{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// MaxBytes is the largest size an entry can have and still be stored.
+func (c *Cache) MaxBytes() int {
+	return c.maxBytes
+}
+
+// Get returns the entry stored under key, unless it expired.
+func (c *Cache) Get(key string, now time.Time) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+	it := element.Value.(*item)
+	if !now.Before(it.expires) {
+		c.remove(element)
+		return Entry{}, false
+	}
+	c.order.MoveToFront(element)
+	return it.entry, true
+}
+
+// Set stores entry under key, replacing any previous one. Entries larger than
+// the size bound are not stored.
+func (c *Cache) Set(key string, entry Entry, now time.Time) {
+	size := len(key) + len(entry.ETag) + len(entry.ContentType) + len(entry.Body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+	if c.ttl <= 0 || size > c.maxBytes {
+		return
+	}
+	for c.size+size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+
+	c.items[key] = c.order.PushFront(&item{key: key, entry: entry, size: size, expires: now.Add(c.ttl)})
+	c.size += size
+}
+
+func (c *Cache) remove(element *list.Element) {
+	it := c.order.Remove(element).(*item)
+	delete(c.items, it.key)
+	c.size -= it.size
+}
@@ -0,0 +1,123 @@
+package responsecache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// entry returns an entry that takes size bytes under a one byte key.
+func entry(size int) Entry {
+	return Entry{Body: []byte(strings.Repeat("x", size-1))}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	type op struct {
+		set  string
+		size int
+		get  string
+	}
+	tests := []struct {
+		name     string
+		maxBytes int
+		ops      []op
+		want     []string
+		wantGone []string
+	}{
+		{
+			name:     "evicts the oldest",
+			maxBytes: 30,
+			ops:      []op{{set: "a", size: 10}, {set: "b", size: 10}, {set: "c", size: 10}, {set: "d", size: 10}},
+			want:     []string{"b", "c", "d"},
+			wantGone: []string{"a"},
+		},
+		{
+			name:     "reading keeps an entry",
+			maxBytes: 30,
+			ops:      []op{{set: "a", size: 10}, {set: "b", size: 10}, {set: "c", size: 10}, {get: "a"}, {set: "d", size: 10}},
+			want:     []string{"a", "c", "d"},
+			wantGone: []string{"b"},
+		},
+		{
+			name:     "evicts as many as needed",
+			maxBytes: 30,
+			ops:      []op{{set: "a", size: 10}, {set: "b", size: 10}, {set: "c", size: 10}, {set: "d", size: 25}},
+			want:     []string{"d"},
+			wantGone: []string{"a", "b", "c"},
+		},
+		{
+			name:     "replacing an entry frees its size",
+			maxBytes: 20,
+			ops:      []op{{set: "a", size: 10}, {set: "a", size: 10}, {set: "b", size: 10}},
+			want:     []string{"a", "b"},
+		},
+		{
+			name:     "too large entries are not stored and drop the previous one",
+			maxBytes: 20,
+			ops:      []op{{set: "a", size: 10}, {set: "b", size: 10}, {set: "a", size: 21}},
+			want:     []string{"b"},
+			wantGone: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(time.Minute, tt.maxBytes)
+			for _, o := range tt.ops {
+				if o.set != "" {
+					cache.Set(o.set, entry(o.size), now)
+				} else {
+					cache.Get(o.get, now)
+				}
+			}
+			if cache.size > tt.maxBytes {
+				t.Errorf("holds %d bytes, above the bound of %d", cache.size, tt.maxBytes)
+			}
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key, now); !ok {
+					t.Errorf("%s was evicted", key)
+				}
+			}
+			for _, key := range tt.wantGone {
+				if _, ok := cache.Get(key, now); ok {
+					t.Errorf("%s is still cached", key)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		after  time.Duration
+		wantOK bool
+	}{
+		{"fresh", time.Minute, 0, true},
+		{"just before the TTL", time.Minute, time.Minute - time.Nanosecond, true},
+		{"at the TTL", time.Minute, time.Minute, false},
+		{"after the TTL", time.Minute, time.Hour, false},
+		{"no TTL stores nothing", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(tt.ttl, 100)
+			cache.Set("key", Entry{ETag: `W/"1"`, Body: []byte("{}")}, now)
+
+			got, ok := cache.Get("key", now.Add(tt.after))
+			if ok != tt.wantOK {
+				t.Fatalf("Get after %s found %v, want %v", tt.after, ok, tt.wantOK)
+			}
+			if ok && (got.ETag != `W/"1"` || string(got.Body) != "{}") {
+				t.Errorf("Get returned %+v", got)
+			}
+			if !ok && cache.size != 0 {
+				t.Errorf("expired entries still take %d bytes", cache.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// anomalyWindow is how recent the dates of the expenses checked are
+	anomalyWindow = 7 * 24 * time.Hour
+	// anomalyHistory is how far back before an expense the ones it is
+	// compared with go
+	anomalyHistory = 180 * 24 * time.Hour
+	// anomalyMinHistory is how many earlier expenses of its category an
+	// expense needs to be judged
+	anomalyMinHistory = 5
+	// anomalyDeviations is how many standard deviations above the average of
+	// its category an anomaly is
+	anomalyDeviations = 3
+	// anomalyMinRatio is how many times the average of its category an
+	// anomaly is at least, so categories of nearly constant amounts are not
+	// flagged for small changes
+	anomalyMinRatio = 2
+)
+
+// AnomalyDetected is the data of anomaly.detected webhook events: an expense
+// far above the earlier ones of its category. Threshold is the amount it was
+// found above.
+type AnomalyDetected struct {
+	TransactionID   int     `json:"transaction_id"`
+	Date            string  `json:"date"`
+	Amount          float64 `json:"amount"`
+	CategoryID      int     `json:"category_id"`
+	CategoryName    string  `json:"category_name"`
+	Description     string  `json:"description"`
+	UserID          *int    `json:"user_id"`
+	CategoryAverage float64 `json:"category_average"`
+	Threshold       float64 `json:"threshold"`
+}
+
+// AnomalyService looks for expenses far above the usual ones of their
+// category and publishes anomaly.detected for each, once.
+type AnomalyService struct {
+	anomalyRepo     repository.AnomalyRepositoryInterface
+	transactionRepo repository.TransactionRepositoryInterface
+	categoryRepo    repository.CategoryRepositoryInterface
+	webhookService  *WebhookService
+	checkInterval   time.Duration
+}
+
+func NewAnomalyService(
+	anomalyRepo repository.AnomalyRepositoryInterface,
+	transactionRepo repository.TransactionRepositoryInterface,
+	categoryRepo repository.CategoryRepositoryInterface,
+	webhookService *WebhookService,
+	checkInterval time.Duration,
+) *AnomalyService {
+	return &AnomalyService{
+		anomalyRepo:     anomalyRepo,
+		transactionRepo: transactionRepo,
+		categoryRepo:    categoryRepo,
+		webhookService:  webhookService,
+		checkInterval:   checkInterval,
+	}
+}
+
+// Run checks the expenses of the last week every checkInterval until ctx is
+// done.
+func (s *AnomalyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDetected(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AnomalyService) publishDetected(ctx context.Context, now time.Time) {
+	anomalies, err := s.detect(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look for anomalies", "component", "AnomalyService.Run", "error", err)
+		return
+	}
+
+	for _, anomaly := range anomalies {
+		marked, err := s.anomalyRepo.MarkAnomalyDetected(ctx, anomaly.TransactionID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record anomaly", "component", "AnomalyService.Run", "transaction_id", anomaly.TransactionID, "error", err)
+			continue
+		}
+		if !marked {
+			// Already published, by this instance or another.
+			continue
+		}
+
+		slog.InfoContext(ctx, "Anomaly detected", "component", "AnomalyService.Run", "transaction_id", anomaly.TransactionID, "category_id", anomaly.CategoryID)
+		if err := s.webhookService.Publish(ctx, domain.EventAnomalyDetected, anomaly); err != nil {
+			slog.ErrorContext(ctx, "Failed to publish anomaly", "component", "AnomalyService.Run", "transaction_id", anomaly.TransactionID, "error", err)
+		}
+	}
+}
+
+// detect returns the expenses dated within anomalyWindow of now that are
+// more than anomalyDeviations standard deviations, and anomalyMinRatio
+// times, above the average of the expenses of their category in the
+// anomalyHistory before them. Expenses are ordered by date.
+func (s *AnomalyService) detect(ctx context.Context, now time.Time) ([]AnomalyDetected, error) {
+	ctx, span := tracing.Start(ctx, "AnomalyService.detect")
+	defer span.End()
+
+	expense := domain.Expense
+	from := now.Add(-anomalyWindow - anomalyHistory)
+	transactions, err := s.transactionRepo.GetTransactions(ctx, repository.TransactionFilter{Type: &expense, From: &from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	categories, err := s.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	categoryNames := make(map[int]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+
+	byCategory := make(map[int][]domain.Transaction)
+	for _, tx := range transactions {
+		if tx.Date != nil {
+			byCategory[tx.CategoryID] = append(byCategory[tx.CategoryID], tx)
+		}
+	}
+
+	var anomalies []AnomalyDetected
+	for categoryID, expenses := range byCategory {
+		sort.Slice(expenses, func(i, j int) bool {
+			if !expenses[i].Date.Equal(*expenses[j].Date) {
+				return expenses[i].Date.Before(*expenses[j].Date)
+			}
+			return expenses[i].ID < expenses[j].ID
+		})
+
+		for i, tx := range expenses {
+			if tx.Date.Before(now.Add(-anomalyWindow)) {
+				continue
+			}
+
+			var history []float64
+			for _, earlier := range expenses[:i] {
+				if !earlier.Date.Before(tx.Date.Add(-anomalyHistory)) {
+					history = append(history, earlier.Amount)
+				}
+			}
+			if len(history) < anomalyMinHistory {
+				continue
+			}
+
+			average, deviation := meanAndDeviation(history)
+			threshold := max(average+anomalyDeviations*deviation, anomalyMinRatio*average)
+			if tx.Amount <= threshold {
+				continue
+			}
+			anomalies = append(anomalies, AnomalyDetected{
+				TransactionID:   tx.ID,
+				Date:            tx.Date.UTC().Format(time.DateOnly),
+				Amount:          tx.Amount,
+				CategoryID:      categoryID,
+				CategoryName:    categoryNames[categoryID],
+				Description:     tx.Description,
+				UserID:          tx.CreatedById,
+				CategoryAverage: math.Round(average*100) / 100,
+				Threshold:       math.Round(threshold*100) / 100,
+			})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Date != anomalies[j].Date {
+			return anomalies[i].Date < anomalies[j].Date
+		}
+		return anomalies[i].TransactionID < anomalies[j].TransactionID
+	})
+	return anomalies, nil
+}
+
+// meanAndDeviation returns the mean and population standard deviation of
+// amounts, which must not be empty.
+func meanAndDeviation(amounts []float64) (float64, float64) {
+	var sum float64
+	for _, amount := range amounts {
+		sum += amount
+	}
+	mean := sum / float64(len(amounts))
+
+	var squares float64
+	for _, amount := range amounts {
+		squares += (amount - mean) * (amount - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(amounts)))
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAnomalyRepository remembers the transactions reported as anomalies.
+type fakeAnomalyRepository struct {
+	mu     sync.Mutex
+	marked map[int]bool
+}
+
+func (r *fakeAnomalyRepository) MarkAnomalyDetected(ctx context.Context, transactionID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.marked[transactionID] {
+		return false, nil
+	}
+	if r.marked == nil {
+		r.marked = make(map[int]bool)
+	}
+	r.marked[transactionID] = true
+	return true, nil
+}
+
+func TestAnomalousExpenseIsPublishedOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	daysAgo := func(days int) *time.Time {
+		date := now.AddDate(0, 0, -days)
+		return &date
+	}
+
+	transactions := &fakeTransactionRepository{}
+	add := func(categoryID int, amount float64, date *time.Time) int {
+		id := len(transactions.transactions) + 1
+		transactions.transactions = append(transactions.transactions, domain.Transaction{ID: id, CategoryID: categoryID, Amount: amount, Type: domain.Expense, Date: date, Description: "expense"})
+		return id
+	}
+	// Groceries usually cost about 100.
+	for i, amount := range []float64{90, 110, 95, 105, 100, 100} {
+		add(1, amount, daysAgo(20+10*i))
+	}
+	anomaly := add(1, 450, daysAgo(1))
+	add(1, 130, daysAgo(2))
+	// Too old to count as history.
+	add(1, 5000, daysAgo(400))
+	// Two earlier expenses are not enough to judge one.
+	add(2, 10, daysAgo(50))
+	add(2, 10, daysAgo(40))
+	add(2, 1000, daysAgo(1))
+	transactions.transactions = append(transactions.transactions, domain.Transaction{ID: 100, CategoryID: 1, Amount: 5000, Type: domain.Income, Date: daysAgo(1)})
+
+	categories := &fakeCategoryRepository{categories: []domain.Category{{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Travel"}}}
+	webhooks := &fakeWebhookRepository{now: now}
+	webhookService := NewWebhookService(webhooks, external.NewWebhookSender(time.Second), 1, time.Minute)
+	if _, err := webhookService.CreateWebhook(ctx, "https://example.com/hook", []domain.EventType{domain.EventAnomalyDetected}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	s := NewAnomalyService(&fakeAnomalyRepository{}, transactions, categories, webhookService, time.Minute)
+
+	s.publishDetected(ctx, now)
+	s.publishDetected(ctx, now)
+
+	got := publishedEvents[AnomalyDetected](t, webhooks, domain.EventAnomalyDetected)
+	if len(got) != 1 {
+		t.Fatalf("published %+v, want one anomaly", got)
+	}
+	if got[0].TransactionID != anomaly || got[0].Amount != 450 || got[0].CategoryName != "Groceries" || got[0].Date != daysAgo(1).Format(time.DateOnly) {
+		t.Errorf("published %+v, want transaction %d of 450 in Groceries", got[0], anomaly)
+	}
+	if got[0].CategoryAverage < 100 || got[0].CategoryAverage > 200 || got[0].Threshold <= got[0].CategoryAverage {
+		t.Errorf("published an average of %.2f and a threshold of %.2f", got[0].CategoryAverage, got[0].Threshold)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apiKeyPrefix marks opaque API keys so they can be told apart from JWTs
+const apiKeyPrefix = "ak_"
+
+var ErrUnauthenticated = errors.New("invalid or missing credentials")
+
+// ValidationError is returned when a request to the service is invalid, as
+// opposed to the service failing to carry it out.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+type IssuedAPIKey struct {
+	APIKey domain.APIKey
+	Key    string
+}
+
+type AuthService struct {
+	apiKeyRepo     *repository.APIKeyRepository
+	jwtSecret      []byte
+	bootstrapKey   string
+	bootstrapScope []domain.Scope
+}
+
+// NewAuthService builds the authenticator. jwtSecret enables HS256 bearer
+// tokens when set, and bootstrapKey is an admin key accepted without a DB
+// lookup so the first real key can be issued.
+func NewAuthService(apiKeyRepo *repository.APIKeyRepository, jwtSecret string, bootstrapKey string) *AuthService {
+	return &AuthService{
+		apiKeyRepo:     apiKeyRepo,
+		jwtSecret:      []byte(jwtSecret),
+		bootstrapKey:   bootstrapKey,
+		bootstrapScope: []domain.Scope{domain.ScopeAdmin},
+	}
+}
+
+// Authenticate resolves a credential to a principal. API keys are looked up
+// by their SHA-256 hash; anything else is treated as a JWT.
+func (s *AuthService) Authenticate(ctx context.Context, credential string) (*domain.Principal, error) {
+	if credential == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	if s.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(s.bootstrapKey)) == 1 {
+		return &domain.Principal{Name: "bootstrap", Scopes: s.bootstrapScope}, nil
+	}
+
+	if strings.HasPrefix(credential, apiKeyPrefix) {
+		key, err := s.apiKeyRepo.GetActiveAPIKeyByHash(ctx, hashAPIKey(credential))
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to look up api key", "component", "AuthService.Authenticate", "error", err)
+			return nil, err
+		}
+		return &domain.Principal{KeyID: &key.ID, Name: key.Name, UserID: key.UserID, Scopes: key.Scopes, Quota: key.Quota}, nil
+	}
+
+	return s.authenticateJWT(credential)
+}
+
+func (s *AuthService) authenticateJWT(token string) (*domain.Principal, error) {
+	if len(s.jwtSecret) == 0 {
+		return nil, ErrUnauthenticated
+	}
+
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	principal := &domain.Principal{Name: claims.Subject}
+	for _, scope := range strings.Fields(claims.Scope) {
+		principal.Scopes = append(principal.Scopes, domain.Scope(scope))
+	}
+	if userID, err := strconv.Atoi(claims.Subject); err == nil {
+		principal.UserID = &userID
+	} else if !principal.HasScope(domain.ScopeAdmin) {
+		// Without a user to scope it to the token would see every user's data.
+		return nil, ErrUnauthenticated
+	}
+
+	return principal, nil
+}
+
+// tokenClaims are the JWT claims we accept: the subject is the user id, which
+// only admin tokens may omit, and scope is a space separated list, as in
+// OAuth 2.0.
+type tokenClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// IssueAPIKey creates a new key. The plaintext key is only ever returned here.
+func (s *AuthService) IssueAPIKey(ctx context.Context, name string, scopes []domain.Scope, userID *int, quota domain.Quota) (*IssuedAPIKey, error) {
+	if name == "" {
+		return nil, &ValidationError{Message: "name is required"}
+	}
+	if len(scopes) == 0 {
+		return nil, &ValidationError{Message: "at least one scope is required"}
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case domain.ScopeReadAnalytics, domain.ScopeRunQuery, domain.ScopeAdmin:
+		default:
+			return nil, &ValidationError{Message: fmt.Sprintf("unknown scope %q", scope)}
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := domain.APIKey{
+		Name:    name,
+		Prefix:  plaintext[:len(apiKeyPrefix)+8],
+		KeyHash: hashAPIKey(plaintext),
+		Scopes:  scopes,
+		UserID:  userID,
+		Quota:   quota,
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, &key); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+func (s *AuthService) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.apiKeyRepo.GetAllAPIKeys(ctx)
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.apiKeyRepo.RevokeAPIKey(ctx, id)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
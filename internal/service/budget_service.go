@@ -0,0 +1,203 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+)
+
+// maxBudgetAmount is the largest amount a budget column stores
+const maxBudgetAmount = 1e10
+
+// BudgetStatus compares a budget with the expenses of its category, including
+// those of its subcategories, over a month or the part of it so far.
+// Remaining is negative once the budget is exceeded.
+type BudgetStatus struct {
+	Budget       domain.Budget
+	CategoryName string
+	Spent        float64
+	Remaining    float64
+	Exceeded     bool
+}
+
+// BudgetExceeded is the data of budget.exceeded webhook events. Month is the
+// UTC month the budget was crossed in, as YYYY-MM.
+type BudgetExceeded struct {
+	BudgetID     int     `json:"budget_id"`
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Month        string  `json:"month"`
+	Amount       float64 `json:"amount"`
+	Spent        float64 `json:"spent"`
+}
+
+type BudgetService struct {
+	budgetRepo      repository.BudgetRepositoryInterface
+	transactionRepo repository.TransactionRepositoryInterface
+	categoryRepo    repository.CategoryRepositoryInterface
+	webhookService  *WebhookService
+	checkInterval   time.Duration
+}
+
+// NewBudgetService builds the budget registry and the publisher of
+// budget.exceeded, which checks the budgets every checkInterval.
+func NewBudgetService(
+	budgetRepo repository.BudgetRepositoryInterface,
+	transactionRepo repository.TransactionRepositoryInterface,
+	categoryRepo repository.CategoryRepositoryInterface,
+	webhookService *WebhookService,
+	checkInterval time.Duration,
+) *BudgetService {
+	return &BudgetService{
+		budgetRepo:      budgetRepo,
+		transactionRepo: transactionRepo,
+		categoryRepo:    categoryRepo,
+		webhookService:  webhookService,
+		checkInterval:   checkInterval,
+	}
+}
+
+func (s *BudgetService) GetBudgets(ctx context.Context) ([]domain.Budget, error) {
+	return s.budgetRepo.GetAllBudgets(ctx)
+}
+
+// SetBudget sets the monthly budget of a category, replacing the one it has.
+func (s *BudgetService) SetBudget(ctx context.Context, categoryID int, amount float64) (*domain.Budget, error) {
+	if math.IsNaN(amount) || amount <= 0 || amount >= maxBudgetAmount {
+		return nil, &ValidationError{Message: fmt.Sprintf("amount must be positive and below %.0f", float64(maxBudgetAmount))}
+	}
+
+	categories, err := s.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	if !slices.ContainsFunc(categories, func(c domain.Category) bool { return c.ID == categoryID }) {
+		return nil, &ValidationError{Message: fmt.Sprintf("unknown category %d", categoryID)}
+	}
+
+	budget := &domain.Budget{CategoryID: categoryID, Amount: math.Round(amount*100) / 100}
+	if err := s.budgetRepo.SetBudget(ctx, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, categoryID int) error {
+	return s.budgetRepo.DeleteBudget(ctx, categoryID)
+}
+
+// GetStatus compares every budget with the expenses of the UTC month of t.
+func (s *BudgetService) GetStatus(ctx context.Context, t time.Time) ([]BudgetStatus, error) {
+	ctx, span := tracing.Start(ctx, "BudgetService.GetStatus")
+	defer span.End()
+
+	start, _ := periodStart(t, PeriodMonth)
+	return s.statusBetween(ctx, start, start.AddDate(0, 1, 0))
+}
+
+// statusBetween compares every budget with the expenses from from, a UTC
+// month start, to to, exclusive, within that month.
+func (s *BudgetService) statusBetween(ctx context.Context, from time.Time, to time.Time) ([]BudgetStatus, error) {
+	budgets, err := s.budgetRepo.GetAllBudgets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch budgets: %w", err)
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	categories, err := s.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	categoryNames := make(map[int]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+	lineage := domain.CategoryLineage(categories)
+
+	expense := domain.Expense
+	totals, err := monthlyTotals(ctx, s.transactionRepo, repository.TransactionFilter{Type: &expense, From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[int]float64)
+	for _, t := range totals {
+		for _, categoryID := range rollup(lineage, t.CategoryID) {
+			spent[categoryID] += t.Amount
+		}
+	}
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		statuses = append(statuses, BudgetStatus{
+			Budget:       budget,
+			CategoryName: categoryNames[budget.CategoryID],
+			Spent:        spent[budget.CategoryID],
+			Remaining:    budget.Amount - spent[budget.CategoryID],
+			Exceeded:     spent[budget.CategoryID] > budget.Amount,
+		})
+	}
+	return statuses, nil
+}
+
+// Run publishes budget.exceeded for the budgets crossed in the current UTC
+// month, checking every checkInterval until ctx is done. Each budget is
+// reported at most once a month, or once more after its amount changes.
+func (s *BudgetService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.publishExceeded(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *BudgetService) publishExceeded(ctx context.Context, now time.Time) {
+	month, _ := periodStart(now, PeriodMonth)
+	statuses, err := s.GetStatus(ctx, month)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check budgets", "component", "BudgetService.Run", "error", err)
+		return
+	}
+
+	for _, status := range statuses {
+		if !status.Exceeded {
+			continue
+		}
+		marked, err := s.budgetRepo.MarkBudgetExceeded(ctx, status.Budget.ID, month)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record exceeded budget", "component", "BudgetService.Run", "budget_id", status.Budget.ID, "error", err)
+			continue
+		}
+		if !marked {
+			// Already published, by this instance or another.
+			continue
+		}
+
+		slog.InfoContext(ctx, "Budget exceeded", "component", "BudgetService.Run", "budget_id", status.Budget.ID, "category_id", status.Budget.CategoryID, "month", month.Format("2006-01"))
+		exceeded := BudgetExceeded{
+			BudgetID:     status.Budget.ID,
+			CategoryID:   status.Budget.CategoryID,
+			CategoryName: status.CategoryName,
+			Month:        month.Format("2006-01"),
+			Amount:       status.Budget.Amount,
+			Spent:        status.Spent,
+		}
+		if err := s.webhookService.Publish(ctx, domain.EventBudgetExceeded, exceeded); err != nil {
+			slog.ErrorContext(ctx, "Failed to publish exceeded budget", "component", "BudgetService.Run", "budget_id", status.Budget.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBudgetRepository keeps budgets and the months they were reported
+// exceeded in in memory.
+type fakeBudgetRepository struct {
+	mu       sync.Mutex
+	budgets  []domain.Budget
+	exceeded map[int][]time.Time
+}
+
+func (r *fakeBudgetRepository) SetBudget(ctx context.Context, budget *domain.Budget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.budgets {
+		if stored.CategoryID == budget.CategoryID {
+			budget.ID = stored.ID
+			if stored.Amount != budget.Amount {
+				delete(r.exceeded, budget.ID)
+			}
+			r.budgets[i] = *budget
+			return nil
+		}
+	}
+	budget.ID = len(r.budgets) + 1
+	r.budgets = append(r.budgets, *budget)
+	return nil
+}
+
+func (r *fakeBudgetRepository) GetAllBudgets(ctx context.Context) ([]domain.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Budget(nil), r.budgets...), nil
+}
+
+func (r *fakeBudgetRepository) DeleteBudget(ctx context.Context, categoryID int) error {
+	return repository.ErrBudgetNotFound
+}
+
+func (r *fakeBudgetRepository) MarkBudgetExceeded(ctx context.Context, budgetID int, month time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, marked := range r.exceeded[budgetID] {
+		if marked.Equal(month) {
+			return false, nil
+		}
+	}
+	if r.exceeded == nil {
+		r.exceeded = make(map[int][]time.Time)
+	}
+	r.exceeded[budgetID] = append(r.exceeded[budgetID], month)
+	return true, nil
+}
+
+// publishedEvents decodes the events queued for delivery, in order.
+func publishedEvents[T any](t *testing.T, repo *fakeWebhookRepository, event domain.EventType) []T {
+	t.Helper()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var data []T
+	for _, delivery := range repo.deliveries {
+		if delivery.EventType != event {
+			continue
+		}
+		var body struct {
+			Data T `json:"data"`
+		}
+		if err := json.Unmarshal(delivery.Payload, &body); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		data = append(data, body.Data)
+	}
+	return data
+}
+
+func TestBudgetExceededIsPublishedOncePerMonth(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	month, _ := periodStart(now, PeriodMonth)
+	inMonth := month.Add(time.Hour)
+	inLastMonth := month.AddDate(0, 0, -1)
+
+	food := 1
+	transactions := &fakeTransactionRepository{transactions: []domain.Transaction{
+		{ID: 1, CategoryID: 2, Amount: 60, Type: domain.Expense, Date: &inMonth},
+		{ID: 2, CategoryID: 1, Amount: 30, Type: domain.Expense, Date: &inMonth},
+		{ID: 3, CategoryID: 2, Amount: 500, Type: domain.Expense, Date: &inLastMonth},
+		{ID: 4, CategoryID: 1, Amount: 500, Type: domain.Income, Date: &inMonth},
+	}}
+	categories := &fakeCategoryRepository{categories: []domain.Category{
+		{ID: 1, Name: "Food"},
+		{ID: 2, Name: "Groceries", ParentID: &food},
+		{ID: 3, Name: "Rent"},
+	}}
+	webhooks := &fakeWebhookRepository{now: now}
+	webhookService := NewWebhookService(webhooks, external.NewWebhookSender(time.Second), 1, time.Minute)
+	if _, err := webhookService.CreateWebhook(ctx, "https://example.com/hook", []domain.EventType{domain.EventBudgetExceeded}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	s := NewBudgetService(&fakeBudgetRepository{}, transactions, categories, webhookService, time.Minute)
+
+	if _, err := s.SetBudget(ctx, 1, 100); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if _, err := s.SetBudget(ctx, 4, 100); err == nil {
+		t.Errorf("SetBudget of an unknown category succeeded")
+	}
+	if _, err := s.SetBudget(ctx, 3, 0); err == nil {
+		t.Errorf("SetBudget of 0 succeeded")
+	}
+
+	// Subcategories count, income and other months do not.
+	statuses, err := s.GetStatus(ctx, now)
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Spent != 90 || statuses[0].Remaining != 10 || statuses[0].Exceeded {
+		t.Fatalf("got %+v, want 90 spent of the 100 of Food", statuses)
+	}
+	s.publishExceeded(ctx, now)
+	if got := publishedEvents[BudgetExceeded](t, webhooks, domain.EventBudgetExceeded); len(got) != 0 {
+		t.Fatalf("published %+v before the budget was exceeded", got)
+	}
+
+	inMonthLater := inMonth.Add(time.Minute)
+	transactions.transactions = append(transactions.transactions, domain.Transaction{ID: 5, CategoryID: 2, Amount: 20, Type: domain.Expense, Date: &inMonthLater})
+	s.publishExceeded(ctx, now)
+	s.publishExceeded(ctx, now)
+
+	got := publishedEvents[BudgetExceeded](t, webhooks, domain.EventBudgetExceeded)
+	want := BudgetExceeded{BudgetID: 1, CategoryID: 1, CategoryName: "Food", Month: month.Format("2006-01"), Amount: 100, Spent: 110}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("published %+v, want once %+v", got, want)
+	}
+
+	// A new amount still exceeded is reported again.
+	if _, err := s.SetBudget(ctx, 1, 105); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	s.publishExceeded(ctx, now)
+	if got := publishedEvents[BudgetExceeded](t, webhooks, domain.EventBudgetExceeded); len(got) != 2 || got[1].Amount != 105 {
+		t.Fatalf("published %+v after changing the amount, want a second event for 105", got)
+	}
+}
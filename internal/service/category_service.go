@@ -1,9 +1,12 @@
 package service
 
 import (
+	"analytics/internal/domain"
 	"analytics/internal/repository"
+	"analytics/internal/tracing"
 	"context"
 	"fmt"
+	"slices"
 )
 
 type AverageCategory struct {
@@ -21,8 +24,11 @@ func NewCategoryService(categoryRepo repository.CategoryRepositoryInterface, tra
 	return &CategoryService{categoryRepo: categoryRepo, transactionRepo: transactionRepo}
 }
 
-func (r *CategoryService) GetAverageByCategory(ctx context.Context) ([]AverageCategory, error) {
-	transactions, err := r.transactionRepo.GetAllTransactions(ctx)
+func (r *CategoryService) GetAverageByCategory(ctx context.Context, filter repository.TransactionFilter) ([]AverageCategory, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.GetAverageByCategory")
+	defer span.End()
+
+	totals, err := monthlyTotals(ctx, r.transactionRepo, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -36,16 +42,19 @@ func (r *CategoryService) GetAverageByCategory(ctx context.Context) ([]AverageCa
 	for _, c := range categories {
 		categoryMap[c.ID] = c.Name
 	}
+	lineage := domain.CategoryLineage(categories)
 
 	monthlySumsByCategory := make(map[string]float64)
 
-	for _, tx := range transactions {
-		monthKey := fmt.Sprintf("%d-%d-%d",
-			tx.CategoryID,
-			tx.Date.Year(),
-			tx.Date.Month())
+	for _, total := range totals {
+		for _, categoryID := range rollup(lineage, total.CategoryID) {
+			monthKey := fmt.Sprintf("%d-%d-%d",
+				categoryID,
+				total.Month.Year(),
+				total.Month.Month())
 
-		monthlySumsByCategory[monthKey] += tx.Amount
+			monthlySumsByCategory[monthKey] += total.Amount
+		}
 	}
 
 	monthlySumsByCategoryID := make(map[int][]float64)
@@ -74,3 +83,37 @@ func (r *CategoryService) GetAverageByCategory(ctx context.Context) ([]AverageCa
 
 	return result, nil
 }
+
+// SetCategoryParent moves a category under parentID, or to the top level when
+// parentID is nil.
+func (r *CategoryService) SetCategoryParent(ctx context.Context, id int, parentID *int) error {
+	categories, err := r.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return err
+	}
+	lineage := domain.CategoryLineage(categories)
+
+	if _, ok := lineage[id]; !ok {
+		return repository.ErrCategoryNotFound
+	}
+	if parentID != nil {
+		ancestors, ok := lineage[*parentID]
+		if !ok {
+			return &ValidationError{Message: fmt.Sprintf("parent category %d does not exist", *parentID)}
+		}
+		if slices.Contains(ancestors, id) {
+			return &ValidationError{Message: fmt.Sprintf("category %d cannot be its own ancestor", id)}
+		}
+	}
+
+	return r.categoryRepo.SetCategoryParent(ctx, id, parentID)
+}
+
+// rollup returns the categories the totals of categoryID count towards: it
+// and its ancestors.
+func rollup(lineage map[int][]int, categoryID int) []int {
+	if ids, ok := lineage[categoryID]; ok {
+		return ids
+	}
+	return []int{categoryID}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/migrations"
+	"analytics/internal/repository"
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusSkipped = "skipped"
+)
+
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+type HealthService struct {
+	schemaRepo    *repository.SchemaRepository
+	migrator      *migrations.Migrator
+	openAIService *external.OpenAIService
+	checkLLM      bool
+	timeout       time.Duration
+}
+
+// NewHealthService builds the readiness checks. The LLM provider is only
+// checked when checkLLM is set, since each check is a request to the provider.
+func NewHealthService(schemaRepo *repository.SchemaRepository, migrator *migrations.Migrator, openAIService *external.OpenAIService, checkLLM bool, timeout time.Duration) *HealthService {
+	return &HealthService{
+		schemaRepo:    schemaRepo,
+		migrator:      migrator,
+		openAIService: openAIService,
+		checkLLM:      checkLLM,
+		timeout:       timeout,
+	}
+}
+
+// GetReadiness runs every dependency check concurrently. The service is ready
+// only when none of them is down.
+func (s *HealthService) GetReadiness(ctx context.Context) Readiness {
+	checks := map[string]func(context.Context) error{
+		"postgres": s.schemaRepo.Ping,
+		"schema":   s.migrator.Check,
+	}
+	if s.checkLLM {
+		checks["openai"] = s.openAIService.Ping
+	}
+
+	results := make(chan DependencyStatus, len(checks))
+	for name, check := range checks {
+		go func() {
+			results <- s.runCheck(ctx, name, check)
+		}()
+	}
+
+	readiness := Readiness{Status: StatusUp}
+	for range checks {
+		status := <-results
+		if status.Status == StatusDown {
+			readiness.Status = StatusDown
+		}
+		readiness.Dependencies = append(readiness.Dependencies, status)
+	}
+
+	if !s.checkLLM {
+		readiness.Dependencies = append(readiness.Dependencies, DependencyStatus{Name: "openai", Status: StatusSkipped})
+	}
+
+	sort.Slice(readiness.Dependencies, func(i, j int) bool {
+		return readiness.Dependencies[i].Name < readiness.Dependencies[j].Name
+	})
+
+	return readiness
+}
+
+func (s *HealthService) runCheck(ctx context.Context, name string, check func(context.Context) error) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := DependencyStatus{
+		Name:      name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"analytics/internal/changes"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxMerchantNameLength  = 100
+	maxMerchantMatchLength = 200
+)
+
+// MerchantSpending sums the expenses at a merchant. FirstSeen and LastSeen
+// are nil when none of them has a date.
+type MerchantSpending struct {
+	Merchant         string
+	Total            float64
+	TransactionCount int
+	// TransactionsPerMonth spreads the transactions over the calendar months
+	// from the first to the last seen, both included.
+	TransactionsPerMonth float64
+	AverageTicket        float64
+	FirstSeen            *time.Time
+	LastSeen             *time.Time
+}
+
+// MerchantService names the merchants behind transaction descriptions with
+// the merchant rules, and reports the spending at each.
+type MerchantService struct {
+	ruleRepo        *repository.MerchantRuleRepository
+	transactionRepo repository.TransactionRepositoryInterface
+	matcher         *changes.Cache[*domain.MerchantMatcher]
+}
+
+func NewMerchantService(ruleRepo *repository.MerchantRuleRepository, transactionRepo repository.TransactionRepositoryInterface, listener *changes.Listener) *MerchantService {
+	return &MerchantService{
+		ruleRepo:        ruleRepo,
+		transactionRepo: transactionRepo,
+		matcher:         changes.NewCache[*domain.MerchantMatcher](listener, changes.MerchantRules),
+	}
+}
+
+func (s *MerchantService) GetRules(ctx context.Context) ([]domain.MerchantRule, error) {
+	return s.ruleRepo.GetAllMerchantRules(ctx)
+}
+
+// CreateRule adds a rule, tried after the existing ones of its kind. Aliases
+// are stored normalized.
+func (s *MerchantService) CreateRule(ctx context.Context, kind domain.MerchantRuleKind, match string, merchant string) (*domain.MerchantRule, error) {
+	merchant = strings.TrimSpace(merchant)
+	if merchant == "" || len(merchant) > maxMerchantNameLength {
+		return nil, &ValidationError{Message: fmt.Sprintf("merchant must be between 1 and %d characters", maxMerchantNameLength)}
+	}
+	if len(match) > maxMerchantMatchLength {
+		return nil, &ValidationError{Message: fmt.Sprintf("match must be at most %d characters", maxMerchantMatchLength)}
+	}
+
+	switch kind {
+	case domain.MerchantAlias:
+		match = domain.NormalizeDescription(match)
+		if match == "" {
+			return nil, &ValidationError{Message: "match must have a word without digits, as descriptions are compared without them"}
+		}
+	case domain.MerchantPattern:
+		if match == "" {
+			return nil, &ValidationError{Message: "match is required"}
+		}
+		if _, err := domain.CompileMerchantPattern(match); err != nil {
+			return nil, &ValidationError{Message: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+	default:
+		return nil, &ValidationError{Message: fmt.Sprintf("unknown kind %q, kinds are alias and pattern", kind)}
+	}
+
+	rule := domain.MerchantRule{Kind: kind, Match: match, Merchant: merchant}
+	if err := s.ruleRepo.CreateMerchantRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *MerchantService) DeleteRule(ctx context.Context, id int) error {
+	return s.ruleRepo.DeleteMerchantRule(ctx, id)
+}
+
+// getMatcher returns the current rules compiled, cached until they change.
+func (s *MerchantService) getMatcher(ctx context.Context) (*domain.MerchantMatcher, error) {
+	return s.matcher.Get(ctx, func(ctx context.Context) (*domain.MerchantMatcher, error) {
+		rules, err := s.ruleRepo.GetAllMerchantRules(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch merchant rules: %w", err)
+		}
+		return domain.NewMerchantMatcher(rules)
+	})
+}
+
+// GetSpendingByMerchant sums the expenses matching filter per merchant,
+// largest total first. Expenses whose description names no merchant are left
+// out.
+func (s *MerchantService) GetSpendingByMerchant(ctx context.Context, filter repository.TransactionFilter) ([]MerchantSpending, error) {
+	ctx, span := tracing.Start(ctx, "MerchantService.GetSpendingByMerchant")
+	defer span.End()
+
+	matcher, err := s.getMatcher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expense := domain.Expense
+	filter.Type = &expense
+	transactions, err := s.transactionRepo.GetTransactions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	byMerchant := make(map[string]*MerchantSpending)
+	for _, tx := range transactions {
+		merchant := matcher.Merchant(tx.Description)
+		if merchant == "" {
+			continue
+		}
+		spending, ok := byMerchant[merchant]
+		if !ok {
+			spending = &MerchantSpending{Merchant: merchant}
+			byMerchant[merchant] = spending
+		}
+
+		spending.Total += tx.Amount
+		spending.TransactionCount++
+		if tx.Date != nil {
+			if spending.FirstSeen == nil || tx.Date.Before(*spending.FirstSeen) {
+				spending.FirstSeen = tx.Date
+			}
+			if spending.LastSeen == nil || tx.Date.After(*spending.LastSeen) {
+				spending.LastSeen = tx.Date
+			}
+		}
+	}
+
+	result := make([]MerchantSpending, 0, len(byMerchant))
+	for _, spending := range byMerchant {
+		months := 1
+		if spending.FirstSeen != nil {
+			first, last := spending.FirstSeen.UTC(), spending.LastSeen.UTC()
+			months = (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+		}
+		spending.TransactionsPerMonth = float64(spending.TransactionCount) / float64(months)
+		spending.AverageTicket = spending.Total / float64(spending.TransactionCount)
+		result = append(result, *spending)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Merchant < result[j].Merchant
+	})
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// monthAligned reports whether filter selects whole UTC months, which the
+// monthly totals answer exactly.
+func monthAligned(filter repository.TransactionFilter) bool {
+	for _, t := range []*time.Time{filter.From, filter.To} {
+		if t == nil {
+			continue
+		}
+		if start, _ := periodStart(*t, PeriodMonth); !start.Equal(*t) {
+			return false
+		}
+	}
+	return true
+}
+
+// monthlyTotalsAnswer reports whether the precomputed monthly totals answer
+// filter: it selects whole UTC months and no tags, which they do not record.
+func monthlyTotalsAnswer(filter repository.TransactionFilter) bool {
+	return filter.Tags == nil && monthAligned(filter)
+}
+
+// monthlyTotals returns the monthly totals of the transactions matching
+// filter, read from the precomputed totals when they answer filter and summed
+// from the transactions otherwise. Transactions without a date are left out.
+func monthlyTotals(ctx context.Context, transactionRepo repository.TransactionRepositoryInterface, filter repository.TransactionFilter) ([]domain.MonthlyTotal, error) {
+	if monthlyTotalsAnswer(filter) {
+		totals, err := transactionRepo.GetMonthlyTotals(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch monthly totals: %w", err)
+		}
+		return totals, nil
+	}
+
+	transactions, err := transactionRepo.GetTransactions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	type totalKey struct {
+		month      time.Time
+		categoryID int
+		txType     domain.Type
+		subtype    string
+		userID     int
+	}
+	index := make(map[totalKey]int)
+	var totals []domain.MonthlyTotal
+
+	for _, tx := range transactions {
+		if tx.Date == nil {
+			continue
+		}
+		month, _ := periodStart(*tx.Date, PeriodMonth)
+		key := totalKey{month: month, categoryID: tx.CategoryID, txType: tx.Type}
+		if tx.Subtype != nil {
+			key.subtype = *tx.Subtype
+		}
+		if tx.CreatedById != nil {
+			key.userID = *tx.CreatedById
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, domain.MonthlyTotal{
+				Month:      key.month,
+				CategoryID: key.categoryID,
+				Type:       key.txType,
+				Subtype:    subtype(key.subtype),
+				UserID:     key.userID,
+			})
+		}
+		totals[i].Amount += tx.Amount
+		totals[i].Count++
+	}
+
+	return totals, nil
+}
+
+// subtype returns s as an optional subtype, none when it is empty.
+func subtype(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -2,16 +2,30 @@ package service
 
 import (
 	"analytics/external"
-	"analytics/internal/db"
+	"analytics/internal/changes"
+	"analytics/internal/domain"
+	"analytics/internal/logging"
+	"analytics/internal/metrics"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// SYSTEM_PROMPT_TO_GET_QUERY is formatted with the categories and tags
+// references.
 const SYSTEM_PROMPT_TO_GET_QUERY = `
 	I want you to, based on a database schema and a user questions,
 	give me the exact Postgres query to get the data the user wants.
@@ -31,6 +45,7 @@ const SYSTEM_PROMPT_TO_GET_QUERY = `
 		category_id INTEGER,
 		amount DECIMAL(12, 2) NOT NULL,
 		type transaction_type NOT NULL,
+		subtype VARCHAR(50),
 		description TEXT,
 		date TIMESTAMP WITH TIME ZONE,
 		frequency VARCHAR(50),
@@ -41,33 +56,38 @@ const SYSTEM_PROMPT_TO_GET_QUERY = `
 		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 	);
 
+	TABLE categories (
+		id SERIAL PRIMARY KEY,
+		parent_id INTEGER REFERENCES categories (id),
+		name VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		deleted_at TIMESTAMP WITH TIME ZONE
+	);
+
+	-- Returns the given category ids and the ids of all their subcategories
+	FUNCTION category_subtree(root_ids INTEGER[]) RETURNS SETOF INTEGER;
+
+	TABLE tags (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(50) NOT NULL UNIQUE
+	);
+
+	TABLE transaction_tags (
+		transaction_id INTEGER NOT NULL REFERENCES transactions (id),
+		tag_id INTEGER NOT NULL REFERENCES tags (id),
+		PRIMARY KEY (transaction_id, tag_id)
+	);
+
 	-------------------------------------
-    Categories reference
-
-	ID  NAME                DESCRIPTION
-	1	Food             	All expenses with food exluding food bought to daily meals. This is meant for food like deliveries and stuff	
-	2	Salary             	Salary	
-	3	Health             	All expenses related to health	
-	4	Needs             	All needs: internet, energy, water etcetera	
-	5	Subscriptions       All subscriptions	
-	6	Grocery             Grocery shop: food, house items, cleaning items etc	
-	7	Car             	All expenses related to my car	
-	8	Useless             All useless things I buy lol	
-	10	Gifts             	all gifts	
-	11	Electronics         All electronics	
-	12	Education           College, courses, material etc	
-	13	Help             	All transactions related to helping people	
-	14	Misc             	Miscellaneous	
-	15	Clothes             All clothes	
-	16	Books             	all books	
-	17	Transportation      Uber, bus, taxis, trains etc	
-	18	Electrodomestics    Eletrodomésticos	
-	19	Games             	all games	
-	20	Pets             	All expenses related to pets	
-	21	Travel             	All expenses related to travelling	
-	22	Housing             Rent and all other housing expenses	
-	23	Furniture           All furniture 	
-	--------------------------------------------------------------------------------
+    Categories reference (subcategories are indented under their parent)
+
+	ID  PARENT  NAME                DESCRIPTION
+%s	--------------------------------------------------------------------------------
+
+	Tags reference
+
+	ID  NAME
+%s	--------------------------------------------------------------------------------
 
 	--------------------------------------
 	-- PostgreSQL database reference end --
@@ -75,6 +95,11 @@ const SYSTEM_PROMPT_TO_GET_QUERY = `
 
 	Instructions:
 		- The types are only meant to store 2 different types: 'income' or 'expense'.
+		- Categories form a hierarchy through parent_id. A question about a category is also about its subcategories,
+		so select its transactions with category_id IN (SELECT category_subtree(ARRAY[<category id>])).
+		- Tags are free-form labels on transactions, such as a trip or an event, and a transaction can have many.
+		Select the transactions of a tag with EXISTS (SELECT 1 FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.transaction_id = t.id AND tg.name = '<tag name>'), so transactions with several tags are not repeated.
 		- Note that the "Salary" category is of type "income", so it should never be used for questions for expenses. Always ensure to be using
 		transactions from type "expense" for it.
 
@@ -132,94 +157,190 @@ const SYSTEM_PROMPT_TO_ANALYZE_RESULTS = `
 	The following are the question and the results: 
 `
 
+const SYSTEM_PROMPT_USER_SCOPE = `
+
+	Scope:
+		- Only transactions created by the user with created_by_id = %d are visible. The transactions table is already
+		filtered to that user, so do not add a created_by_id condition yourself.
+
+	User question:
+`
+
+// QueryService answers natural language questions by having the LLM write a
+// query, running it as the analytics_query role and having the LLM explain the
+// results.
 type QueryService struct {
-	query      string
-	userPrompt string
+	pool *pgxpool.Pool
+	// queryPool connects as analytics_query, which can only read the views of
+	// the nl_query schema.
+	queryPool     *pgxpool.Pool
+	openAIService *external.OpenAIService
+	categoryRepo  repository.CategoryRepositoryInterface
+	tagRepo       *repository.TagRepository
+	schemaPrompt  *changes.Cache[string]
+
+	mu          sync.Mutex
+	scopeSecret string
+}
+
+func NewQueryService(pool *pgxpool.Pool, queryPool *pgxpool.Pool, openAIService *external.OpenAIService, categoryRepo repository.CategoryRepositoryInterface, tagRepo *repository.TagRepository, listener *changes.Listener) *QueryService {
+	return &QueryService{
+		pool:          pool,
+		queryPool:     queryPool,
+		openAIService: openAIService,
+		categoryRepo:  categoryRepo,
+		tagRepo:       tagRepo,
+		schemaPrompt:  changes.NewCache[string](listener, changes.Categories, changes.Tags),
+	}
+}
+
+// getSchemaPrompt returns the prompt asking for a query, with the categories
+// reference listing the current categories as a tree and the tags reference
+// listing the current tags.
+func (q *QueryService) getSchemaPrompt(ctx context.Context) (string, error) {
+	return q.schemaPrompt.Get(ctx, func(ctx context.Context) (string, error) {
+		categories, err := q.categoryRepo.GetAllCategories(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch categories: %w", err)
+		}
+
+		// Deleted categories are left out, their subcategories taking their place.
+		var reference strings.Builder
+		var list func(nodes []domain.CategoryNode, depth int)
+		list = func(nodes []domain.CategoryNode, depth int) {
+			for _, node := range nodes {
+				if node.DeletedAt.Valid {
+					list(node.Children, depth)
+					continue
+				}
+				parent := "-"
+				if node.ParentID != nil {
+					parent = strconv.Itoa(*node.ParentID)
+				}
+				fmt.Fprintf(&reference, "\t%d\t%-8s%-20s%s\n", node.ID, parent, strings.Repeat("  ", depth)+node.Name, node.Description)
+				list(node.Children, depth+1)
+			}
+		}
+		list(domain.CategoryTree(categories), 0)
+
+		tags, err := q.tagRepo.GetAllTags(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch tags: %w", err)
+		}
+		var tagReference strings.Builder
+		for _, tag := range tags {
+			fmt.Fprintf(&tagReference, "\t%d\t%s\n", tag.ID, tag.Name)
+		}
+		return fmt.Sprintf(SYSTEM_PROMPT_TO_GET_QUERY, reference.String(), tagReference.String()), nil
+	})
 }
 
-func (q *QueryService) GetQuery(prompt string) string {
-	openAIService := &external.OpenAIService{}
-	openAIService.GetOpenAIClient()
-	q.query = openAIService.Ask(prompt)
+func (q *QueryService) GetQuery(ctx context.Context, prompt string) (string, external.Usage, error) {
+	ctx, span := tracing.Start(ctx, "QueryService.GetQuery")
+	defer span.End()
+
+	query, usage, err := q.openAIService.Ask(ctx, prompt)
+	if err != nil {
+		span.RecordError(err)
+		return "", usage, err
+	}
 
-	log.Printf("Query: %v", q.query)
+	slog.DebugContext(ctx, "Generated query", "component", "QueryService.GetQuery", "query", query)
 
-	return q.query
+	return query, usage, nil
 }
 
-func (q *QueryService) RunQuery() (pgx.Rows, error) {
-	databaseService := &db.DatabaseService{}
-	pool := databaseService.GetPool()
+// RunQuery executes the generated query in a read-only transaction of the
+// analytics_query role, which callers roll back once they read the rows. The
+// role only reads the nl_query views, and their transactions are those of the
+// user in the signed analytics.query_scope setting, which the generated SQL
+// cannot forge.
+func (q *QueryService) RunQuery(ctx context.Context, query string, filter repository.TransactionFilter) (pgx.Tx, pgx.Rows, error) {
+	scope, err := q.signScope(ctx, filter.CreatedByID)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	rows, err := pool.Query(context.Background(), q.query)
+	tx, err := q.queryPool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT set_config('analytics.query_scope', $1, true)", scope); err != nil {
+		tx.Rollback(ctx)
+		return nil, nil, fmt.Errorf("failed to scope query: %w", err)
+	}
+
+	observe := metrics.ObserveDBQuery("nl_query")
+	rows, err := tx.Query(ctx, query)
+	observe()
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, nil, err
 	}
 
-	return rows, nil
+	return tx, rows, nil
 }
 
-func (q *QueryService) ConvertResult(rows pgx.Rows) (gin.H, error) {
-	log.Printf("Starting ConvertResult function")
+// signScope returns the analytics.query_scope value limiting queries to the
+// transactions of userID, or to every transaction when it is nil.
+func (q *QueryService) signScope(ctx context.Context, userID *int) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.scopeSecret == "" {
+		if err := q.pool.QueryRow(ctx, `SELECT secret FROM query_scope_secret`).Scan(&q.scopeSecret); err != nil {
+			return "", fmt.Errorf("failed to read query scope secret: %w", err)
+		}
+	}
+
+	subject := "all"
+	if userID != nil {
+		subject = strconv.Itoa(*userID)
+	}
+	// Verified by nl_query.scoped_user_id with pgcrypto's hmac(subject, key, 'sha256').
+	mac := hmac.New(sha256.New, []byte(q.scopeSecret))
+	mac.Write([]byte(subject))
+	return subject + "." + hex.EncodeToString(mac.Sum(nil)), nil
+}
 
+func (q *QueryService) ConvertResult(ctx context.Context, query string, rows pgx.Rows) (gin.H, error) {
 	if rows == nil {
-		log.Printf("Error: rows is nil")
+		slog.ErrorContext(ctx, "Rows is nil", "component", "QueryService.ConvertResult")
 		return nil, fmt.Errorf("rows is nil")
 	}
 
 	fieldDescriptions := rows.FieldDescriptions()
-	log.Printf("Field descriptions count: %d", len(fieldDescriptions))
 
 	columns := make([]string, len(fieldDescriptions))
 	for i, fd := range fieldDescriptions {
 		columns[i] = string(fd.Name)
-		log.Printf("Column %d: %s", i, columns[i])
 	}
 
 	var results []map[string]interface{}
 	rowCount := 0
 
-	hasNext := rows.Next()
-	log.Printf("First rows.Next() returned: %v", hasNext)
-
-	if hasNext {
+	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
-			log.Printf("Error getting row values: %v", err)
+			slog.ErrorContext(ctx, "Error getting row values", "component", "QueryService.ConvertResult", "row", rowCount, "error", err)
 			return nil, err
 		}
-		log.Printf("Raw values for row %d: %+v", rowCount, values)
-		log.Printf("Row %d values count: %d", rowCount, len(values))
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			log.Printf("Row %d, Column %s, Raw value: %v, Type: %T", rowCount, col, val, val)
-			row[col] = val
+			row[col] = values[i]
 		}
 		results = append(results, row)
 		rowCount++
+	}
 
-		for rows.Next() {
-			values, err := rows.Values()
-			if err != nil {
-				log.Printf("Error getting row values: %v", err)
-				return nil, err
-			}
-			log.Printf("Raw values for row %d: %+v", rowCount, values)
-			log.Printf("Row %d values count: %d", rowCount, len(values))
-
-			row := make(map[string]interface{})
-			for i, col := range columns {
-				val := values[i]
-				log.Printf("Row %d, Column %s, Raw value: %v, Type: %T", rowCount, col, val, val)
-				row[col] = val
-			}
-			results = append(results, row)
-			rowCount++
-		}
-	} else {
-		log.Printf("No rows returned from query")
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error iterating rows", "component", "QueryService.ConvertResult", "error", err)
+		return nil, err
+	}
+
+	if rowCount == 0 {
 		row := make(map[string]interface{})
 		for _, col := range columns {
 			row[col] = nil
@@ -227,58 +348,132 @@ func (q *QueryService) ConvertResult(rows pgx.Rows) (gin.H, error) {
 		results = append(results, row)
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Error iterating rows: %v", err)
-		return nil, err
-	}
-
-	log.Printf("Total rows processed: %d", rowCount)
-	log.Printf("Results array length: %d", len(results))
-	log.Printf("Results content: %+v", results)
+	slog.DebugContext(ctx, "Converted query results",
+		"component", "QueryService.ConvertResult",
+		"columns", columns,
+		"rows", rowCount,
+		logging.Sensitive("results", results),
+	)
 
 	response := gin.H{
-		"query":   q.query,
+		"query":   query,
 		"columns": columns,
 		"results": results,
 	}
 
-	log.Printf("Final response structure: query=%v, columns=%v, results length=%d",
-		q.query, columns, len(results))
-
 	return response, nil
 }
 
-func (q *QueryService) AnalyzeDatabase(userPrompt string) (string, error) {
-	q.userPrompt = userPrompt
-	prompt := SYSTEM_PROMPT_TO_GET_QUERY + userPrompt
-	q.GetQuery(prompt)
+// QueryStage is a step of answering a question, reported by AnalyzeDatabaseStream
+type QueryStage string
+
+const (
+	QueryStageGeneratingQuery  QueryStage = "generating_query"
+	QueryStageRunningQuery     QueryStage = "running_query"
+	QueryStageAnalyzingResults QueryStage = "analyzing_results"
+)
+
+// QueryEvent is either the start of a stage or a piece of the answer
+type QueryEvent struct {
+	Stage       QueryStage
+	AnswerDelta string
+}
+
+// AnalyzeDatabase answers a natural language question about the data. The
+// returned usage covers every completion made, including failed ones, so it
+// can be accounted for even when an error is returned.
+func (q *QueryService) AnalyzeDatabase(ctx context.Context, userPrompt string, filter repository.TransactionFilter) (string, []external.Usage, error) {
+	return q.analyzeDatabase(ctx, userPrompt, filter, nil)
+}
+
+// AnalyzeDatabaseStream is AnalyzeDatabase reporting each stage and the answer
+// as it is written to emit. An error from emit aborts the question.
+func (q *QueryService) AnalyzeDatabaseStream(ctx context.Context, userPrompt string, filter repository.TransactionFilter, emit func(QueryEvent) error) (string, []external.Usage, error) {
+	return q.analyzeDatabase(ctx, userPrompt, filter, emit)
+}
+
+func (q *QueryService) analyzeDatabase(ctx context.Context, userPrompt string, filter repository.TransactionFilter, emit func(QueryEvent) error) (response string, usage []external.Usage, err error) {
+	ctx, span := tracing.Start(ctx, "QueryService.AnalyzeDatabase")
+	defer func() { tracing.EndWithError(span, err) }()
+
+	report := func(stage QueryStage) error {
+		if emit == nil {
+			return nil
+		}
+		return emit(QueryEvent{Stage: stage})
+	}
+
+	if err := report(QueryStageGeneratingQuery); err != nil {
+		return "", nil, err
+	}
 
-	rows, err := q.RunQuery()
+	prompt, err := q.getSchemaPrompt(ctx)
 	if err != nil {
-		log.Printf("Error running query: %v", err)
-		return "", err
+		return "", nil, err
+	}
+	if filter.CreatedByID != nil {
+		prompt += fmt.Sprintf(SYSTEM_PROMPT_USER_SCOPE, *filter.CreatedByID)
 	}
+	prompt += userPrompt
 
-	results, err := q.ConvertResult(rows)
+	query, queryUsage, err := q.GetQuery(ctx, prompt)
+	usage = append(usage, queryUsage)
 	if err != nil {
-		log.Printf("Error converting results: %v", err)
-		return "", err
+		slog.ErrorContext(ctx, "Error generating query", "component", "QueryService.AnalyzeDatabase", "error", err)
+		return "", usage, err
+	}
+
+	if err := report(QueryStageRunningQuery); err != nil {
+		return "", usage, err
+	}
+
+	runCtx, runSpan := tracing.Start(ctx, "QueryService.RunQuery")
+	tx, rows, err := q.RunQuery(runCtx, query, filter)
+	if err != nil {
+		tracing.EndWithError(runSpan, err)
+		slog.ErrorContext(ctx, "Error running query", "component", "QueryService.AnalyzeDatabase", "query", query, "error", err)
+		return "", usage, err
+	}
+
+	// The transaction and its connection are released before asking the LLM
+	// to explain the results, which takes far longer than the query.
+	results, err := q.ConvertResult(runCtx, query, rows)
+	rows.Close()
+	tx.Rollback(ctx)
+	tracing.EndWithError(runSpan, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error converting results", "component", "QueryService.AnalyzeDatabase", "error", err)
+		return "", usage, err
 	}
 
 	jsonData, err := json.Marshal(results)
 	if err != nil {
-		log.Printf("Error marshaling results to JSON: %v", err)
-		return "", err
+		slog.ErrorContext(ctx, "Error marshaling results to JSON", "component", "QueryService.AnalyzeDatabase", "error", err)
+		return "", usage, err
 	}
 
-	openAIService := &external.OpenAIService{}
-	openAIService.GetOpenAIClient()
-	resultsPrompt := SYSTEM_PROMPT_TO_ANALYZE_RESULTS + q.userPrompt + string(jsonData)
-	log.Printf("Results prompt: %v", resultsPrompt)
+	resultsPrompt := SYSTEM_PROMPT_TO_ANALYZE_RESULTS + userPrompt + string(jsonData)
+	slog.DebugContext(ctx, "Results prompt", "component", "QueryService.AnalyzeDatabase", logging.Sensitive("prompt", resultsPrompt))
 
-	response := openAIService.Ask(resultsPrompt)
+	if err := report(QueryStageAnalyzingResults); err != nil {
+		return "", usage, err
+	}
 
-	log.Printf("AI response: %v", response)
+	var answerUsage external.Usage
+	if emit == nil {
+		response, answerUsage, err = q.openAIService.Ask(ctx, resultsPrompt)
+	} else {
+		response, answerUsage, err = q.openAIService.AskStream(ctx, resultsPrompt, func(delta string) error {
+			return emit(QueryEvent{AnswerDelta: delta})
+		})
+	}
+	usage = append(usage, answerUsage)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error analyzing results", "component", "QueryService.AnalyzeDatabase", "error", err)
+		return "", usage, err
+	}
 
-	return response, nil
+	slog.DebugContext(ctx, "AI response", "component", "QueryService.AnalyzeDatabase", logging.Sensitive("response", response))
+
+	return response, usage, nil
 }
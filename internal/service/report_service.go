@@ -0,0 +1,450 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// topTransactionsCount is how many of the largest expenses a report lists
+	topTransactionsCount = 5
+	// reportsEndpoint is what narratives are recorded under in the LLM usage
+	reportsEndpoint = "reports"
+)
+
+const reportNarrativePrompt = `
+	You write short personal finance summaries for a household.
+	Based on the following %s report for %s to %s, as JSON, write two or three
+	short paragraphs in plain text, without markdown, that point out what
+	changed against the previous period and anything worth attention.
+	Amounts are in the household's currency; do not name a currency.
+	Category totals include those of their subcategories, the categories
+	naming them as parent_category_id, so do not add them together.
+	Budgets compare the expenses of the month the period ends in, so far,
+	with the monthly budget of their category.
+
+	%s
+`
+
+// reportPrincipal is who narratives are billed to, as they are not requested by a client
+var reportPrincipal = &domain.Principal{Name: "reports"}
+
+// ReportOptions are the settings of the report scheduler and its delivery
+type ReportOptions struct {
+	Periods       []domain.ReportPeriod
+	CheckInterval time.Duration
+	Narrative     bool
+	EmailTo       []string
+}
+
+// ReportReady is the data of report.ready webhook events. The period end is
+// the last day of the period.
+type ReportReady struct {
+	ReportID    int                  `json:"report_id"`
+	Period      domain.ReportPeriod  `json:"period"`
+	PeriodStart string               `json:"period_start"`
+	PeriodEnd   string               `json:"period_end"`
+	Summary     domain.ReportSummary `json:"summary"`
+	Narrative   *string              `json:"narrative"`
+}
+
+type ReportService struct {
+	reportRepo      repository.ReportRepositoryInterface
+	transactionRepo repository.TransactionRepositoryInterface
+	categoryRepo    repository.CategoryRepositoryInterface
+	budgetService   *BudgetService
+	openAIService   *external.OpenAIService
+	usageService    *UsageService
+	webhookService  *WebhookService
+	mailer          *external.Mailer
+	options         ReportOptions
+}
+
+func NewReportService(
+	reportRepo repository.ReportRepositoryInterface,
+	transactionRepo repository.TransactionRepositoryInterface,
+	categoryRepo repository.CategoryRepositoryInterface,
+	budgetService *BudgetService,
+	openAIService *external.OpenAIService,
+	usageService *UsageService,
+	webhookService *WebhookService,
+	mailer *external.Mailer,
+	options ReportOptions,
+) *ReportService {
+	return &ReportService{
+		reportRepo:      reportRepo,
+		transactionRepo: transactionRepo,
+		categoryRepo:    categoryRepo,
+		budgetService:   budgetService,
+		openAIService:   openAIService,
+		usageService:    usageService,
+		webhookService:  webhookService,
+		mailer:          mailer,
+		options:         options,
+	}
+}
+
+func (s *ReportService) GetReport(ctx context.Context, id int) (*domain.Report, error) {
+	return s.reportRepo.GetReport(ctx, id)
+}
+
+func (s *ReportService) GetReports(ctx context.Context, period *domain.ReportPeriod, limit int) ([]domain.Report, error) {
+	return s.reportRepo.GetReports(ctx, period, limit)
+}
+
+// Generate produces the report of the period containing date, or of the last
+// complete period when date is nil, replacing any stored one, and delivers it.
+func (s *ReportService) Generate(ctx context.Context, period domain.ReportPeriod, date *time.Time) (*domain.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.Generate")
+	defer span.End()
+
+	now := time.Now()
+	if date == nil {
+		current, _, err := reportWindow(period, now)
+		if err != nil {
+			return nil, err
+		}
+		previous := current.AddDate(0, 0, -1)
+		date = &previous
+	}
+
+	start, end, err := reportWindow(period, *date)
+	if err != nil {
+		return nil, err
+	}
+	if end.After(now) {
+		return nil, &ValidationError{Message: "the period has not ended yet"}
+	}
+
+	report, err := s.build(ctx, period, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	s.deliver(ctx, report)
+	return report, nil
+}
+
+// Run produces and delivers the report of every configured period once the
+// period is over, checking every CheckInterval until ctx is done. A report
+// missed while the service was down is produced on the first check, as long
+// as its period is still the last complete one.
+func (s *ReportService) Run(ctx context.Context) {
+	if len(s.options.Periods) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.produceDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReportService) produceDue(ctx context.Context) {
+	for _, period := range s.options.Periods {
+		current, _, err := reportWindow(period, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Invalid report period", "component", "ReportService.Run", "period", period, "error", err)
+			continue
+		}
+		start, end, _ := reportWindow(period, current.AddDate(0, 0, -1))
+
+		exists, err := s.reportRepo.HasReport(ctx, period, start)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to look up report", "component", "ReportService.Run", "period", period, "error", err)
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		report, err := s.build(ctx, period, start, end)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to build report", "component", "ReportService.Run", "period", period, "error", err)
+			continue
+		}
+		created, err := s.reportRepo.CreateReport(ctx, report)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to store report", "component", "ReportService.Run", "period", period, "error", err)
+			continue
+		}
+		if !created {
+			// Another instance got there first and delivers it.
+			continue
+		}
+
+		slog.InfoContext(ctx, "Report produced", "component", "ReportService.Run", "report_id", report.ID, "period", period, "period_start", start)
+		s.deliver(ctx, report)
+	}
+}
+
+func (s *ReportService) build(ctx context.Context, period domain.ReportPeriod, start time.Time, end time.Time) (*domain.Report, error) {
+	previousStart, _, _ := reportWindow(period, start.AddDate(0, 0, -1))
+
+	current, err := s.transactionRepo.GetTransactions(ctx, repository.TransactionFilter{From: &start, To: &end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	previous, err := s.transactionRepo.GetTransactions(ctx, repository.TransactionFilter{From: &previousStart, To: &start})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch previous transactions: %w", err)
+	}
+	categories, err := s.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	month, _ := periodStart(end.AddDate(0, 0, -1), PeriodMonth)
+	budgets, err := s.budgetService.statusBetween(ctx, month, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare budgets: %w", err)
+	}
+
+	categoryNames := make(map[int]string, len(categories))
+	categoryParents := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+		categoryParents[c.ID] = c.ParentID
+	}
+	lineage := domain.CategoryLineage(categories)
+
+	summary := domain.ReportSummary{
+		Categories:      []domain.ReportCategory{},
+		TopTransactions: []domain.ReportTransaction{},
+		Budgets:         []domain.ReportBudget{},
+	}
+	byCategory := make(map[int]*domain.ReportCategory)
+	categoryTotals := func(categoryID int) *domain.ReportCategory {
+		if _, ok := byCategory[categoryID]; !ok {
+			byCategory[categoryID] = &domain.ReportCategory{CategoryID: categoryID, CategoryName: categoryNames[categoryID], ParentCategoryID: categoryParents[categoryID]}
+		}
+		return byCategory[categoryID]
+	}
+
+	var expenses []domain.Transaction
+	for _, tx := range current {
+		if tx.Type == domain.Income {
+			summary.Income += tx.Amount
+			continue
+		}
+		summary.Expense += tx.Amount
+		for _, categoryID := range rollup(lineage, tx.CategoryID) {
+			categoryTotals(categoryID).Total += tx.Amount
+		}
+		expenses = append(expenses, tx)
+	}
+	for _, tx := range previous {
+		if tx.Type == domain.Income {
+			summary.PreviousIncome += tx.Amount
+			continue
+		}
+		summary.PreviousExpense += tx.Amount
+		for _, categoryID := range rollup(lineage, tx.CategoryID) {
+			categoryTotals(categoryID).PreviousTotal += tx.Amount
+		}
+	}
+
+	for _, category := range byCategory {
+		if category.PreviousTotal > 0 {
+			change := (category.Total - category.PreviousTotal) / category.PreviousTotal * 100
+			category.ChangePercent = &change
+		}
+		summary.Categories = append(summary.Categories, *category)
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		if summary.Categories[i].Total != summary.Categories[j].Total {
+			return summary.Categories[i].Total > summary.Categories[j].Total
+		}
+		return summary.Categories[i].CategoryID < summary.Categories[j].CategoryID
+	})
+
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return expenses[i].Amount > expenses[j].Amount
+	})
+	for _, tx := range expenses[:min(len(expenses), topTransactionsCount)] {
+		summary.TopTransactions = append(summary.TopTransactions, domain.ReportTransaction{
+			ID:           tx.ID,
+			Date:         tx.Date,
+			Amount:       tx.Amount,
+			CategoryID:   tx.CategoryID,
+			CategoryName: categoryNames[tx.CategoryID],
+			Description:  tx.Description,
+		})
+	}
+
+	for _, budget := range budgets {
+		summary.Budgets = append(summary.Budgets, domain.ReportBudget{
+			CategoryID:   budget.Budget.CategoryID,
+			CategoryName: budget.CategoryName,
+			Amount:       budget.Budget.Amount,
+			Spent:        budget.Spent,
+			Remaining:    budget.Remaining,
+			Exceeded:     budget.Exceeded,
+		})
+	}
+
+	report := &domain.Report{
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Summary:     summary,
+	}
+	if s.options.Narrative {
+		report.Narrative = s.narrate(ctx, report)
+	}
+	return report, nil
+}
+
+// narrate asks the LLM to describe the report. Reports are still produced
+// without a narrative when that fails.
+func (s *ReportService) narrate(ctx context.Context, report *domain.Report) *string {
+	summary, err := json.Marshal(report.Summary)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode report summary", "component", "ReportService.narrate", "error", err)
+		return nil
+	}
+
+	prompt := fmt.Sprintf(reportNarrativePrompt, report.Period, report.PeriodStart.Format(time.DateOnly), lastDay(report).Format(time.DateOnly), summary)
+	answer, usage, err := s.openAIService.Ask(ctx, prompt)
+	if recordErr := s.usageService.RecordUsage(ctx, reportPrincipal, reportsEndpoint, []external.Usage{usage}); recordErr != nil {
+		slog.ErrorContext(ctx, "Failed to record LLM usage", "component", "ReportService.narrate", "error", recordErr)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write report narrative", "component", "ReportService.narrate", "error", err)
+		return nil
+	}
+
+	answer = strings.TrimSpace(answer)
+	return &answer
+}
+
+// deliver publishes a report.ready event and emails the report when
+// recipients are configured. Failures are logged: the report is stored and
+// can be generated again to retry.
+func (s *ReportService) deliver(ctx context.Context, report *domain.Report) {
+	ready := ReportReady{
+		ReportID:    report.ID,
+		Period:      report.Period,
+		PeriodStart: report.PeriodStart.Format(time.DateOnly),
+		PeriodEnd:   lastDay(report).Format(time.DateOnly),
+		Summary:     report.Summary,
+		Narrative:   report.Narrative,
+	}
+	if err := s.webhookService.Publish(ctx, domain.EventReportReady, ready); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish report", "component", "ReportService.deliver", "report_id", report.ID, "error", err)
+	}
+
+	if len(s.options.EmailTo) == 0 {
+		return
+	}
+	if err := s.mailer.Send(ctx, s.options.EmailTo, reportSubject(report), renderReport(report)); err != nil {
+		slog.ErrorContext(ctx, "Failed to email report", "component", "ReportService.deliver", "report_id", report.ID, "error", err)
+	}
+}
+
+// reportWindow returns the UTC start and exclusive end of the period of t.
+// Weeks start on Monday.
+func reportWindow(period domain.ReportPeriod, t time.Time) (time.Time, time.Time, error) {
+	switch period {
+	case domain.ReportWeekly:
+		start, _ := periodStart(t, PeriodWeek)
+		return start, start.AddDate(0, 0, 7), nil
+	case domain.ReportMonthly:
+		start, _ := periodStart(t, PeriodMonth)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, &ValidationError{Message: fmt.Sprintf("unknown report period %q", period)}
+}
+
+func lastDay(report *domain.Report) time.Time {
+	return report.PeriodEnd.AddDate(0, 0, -1)
+}
+
+func reportSubject(report *domain.Report) string {
+	if report.Period == domain.ReportMonthly {
+		return "Monthly report: " + report.PeriodStart.Format("January 2006")
+	}
+	return fmt.Sprintf("Weekly report: %s to %s", report.PeriodStart.Format(time.DateOnly), lastDay(report).Format(time.DateOnly))
+}
+
+// renderReport lays the report out as plain text for email.
+func renderReport(report *domain.Report) string {
+	summary := report.Summary
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n\n", reportSubject(report))
+	fmt.Fprintf(&b, "Income:   %10.2f  (previous %.2f%s)\n", summary.Income, summary.PreviousIncome, formatChange(summary.Income, summary.PreviousIncome))
+	fmt.Fprintf(&b, "Expenses: %10.2f  (previous %.2f%s)\n", summary.Expense, summary.PreviousExpense, formatChange(summary.Expense, summary.PreviousExpense))
+
+	if len(summary.Categories) > 0 {
+		b.WriteString("\nExpenses by category\n")
+		for _, c := range summary.Categories {
+			fmt.Fprintf(&b, "  %-20s %10.2f  (previous %.2f%s)\n", categoryLabel(c.CategoryName, c.CategoryID), c.Total, c.PreviousTotal, formatChange(c.Total, c.PreviousTotal))
+		}
+	}
+
+	if len(summary.TopTransactions) > 0 {
+		b.WriteString("\nLargest expenses\n")
+		for _, tx := range summary.TopTransactions {
+			date := "          "
+			if tx.Date != nil {
+				date = tx.Date.UTC().Format(time.DateOnly)
+			}
+			fmt.Fprintf(&b, "  %s  %-20s %10.2f  %s\n", date, categoryLabel(tx.CategoryName, tx.CategoryID), tx.Amount, tx.Description)
+		}
+	}
+
+	if len(summary.Budgets) > 0 {
+		if report.Period == domain.ReportMonthly {
+			b.WriteString("\nBudgets\n")
+		} else {
+			fmt.Fprintf(&b, "\nBudgets of %s so far\n", lastDay(report).Format("January 2006"))
+		}
+		for _, budget := range summary.Budgets {
+			status := fmt.Sprintf("%.2f left", budget.Remaining)
+			if budget.Exceeded {
+				status = fmt.Sprintf("exceeded by %.2f", -budget.Remaining)
+			}
+			fmt.Fprintf(&b, "  %-20s %10.2f of %.2f  %s\n", categoryLabel(budget.CategoryName, budget.CategoryID), budget.Spent, budget.Amount, status)
+		}
+	}
+
+	if report.Narrative != nil {
+		fmt.Fprintf(&b, "\n%s\n", *report.Narrative)
+	}
+
+	return b.String()
+}
+
+func formatChange(current float64, previous float64) string {
+	if previous == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %+.1f%%", (current-previous)/previous*100)
+}
+
+func categoryLabel(name string, id int) string {
+	if name == "" {
+		return fmt.Sprintf("category %d", id)
+	}
+	return name
+}
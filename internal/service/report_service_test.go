@@ -0,0 +1,259 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/config"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReportRepository keeps reports in memory, one per period start.
+type fakeReportRepository struct {
+	mu      sync.Mutex
+	reports []domain.Report
+}
+
+func (r *fakeReportRepository) CreateReport(ctx context.Context, report *domain.Report) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.reports {
+		if stored.Period == report.Period && stored.PeriodStart.Equal(report.PeriodStart) {
+			return false, nil
+		}
+	}
+	report.ID = len(r.reports) + 1
+	report.CreatedAt = time.Now()
+	r.reports = append(r.reports, *report)
+	return true, nil
+}
+
+func (r *fakeReportRepository) SaveReport(ctx context.Context, report *domain.Report) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeReportRepository) HasReport(ctx context.Context, period domain.ReportPeriod, start time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.reports {
+		if stored.Period == period && stored.PeriodStart.Equal(start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeReportRepository) GetReport(ctx context.Context, id int) (*domain.Report, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeReportRepository) GetReports(ctx context.Context, period *domain.ReportPeriod, limit int) ([]domain.Report, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeTransactionRepository filters a fixed set of transactions by date and
+// type.
+type fakeTransactionRepository struct {
+	transactions []domain.Transaction
+}
+
+func (r *fakeTransactionRepository) GetAllTransactions(ctx context.Context) ([]domain.Transaction, error) {
+	return r.transactions, nil
+}
+
+func (r *fakeTransactionRepository) GetTransactions(ctx context.Context, filter repository.TransactionFilter) ([]domain.Transaction, error) {
+	var matching []domain.Transaction
+	for _, tx := range r.transactions {
+		if tx.Date == nil || (filter.From != nil && tx.Date.Before(*filter.From)) || (filter.To != nil && !tx.Date.Before(*filter.To)) {
+			continue
+		}
+		if filter.Type != nil && tx.Type != *filter.Type {
+			continue
+		}
+		matching = append(matching, tx)
+	}
+	return matching, nil
+}
+
+// GetMonthlyTotals returns a total of its own for each matching transaction,
+// which callers sum up like any other.
+func (r *fakeTransactionRepository) GetMonthlyTotals(ctx context.Context, filter repository.TransactionFilter) ([]domain.MonthlyTotal, error) {
+	transactions, _ := r.GetTransactions(ctx, filter)
+	totals := make([]domain.MonthlyTotal, 0, len(transactions))
+	for _, tx := range transactions {
+		month, _ := periodStart(*tx.Date, PeriodMonth)
+		totals = append(totals, domain.MonthlyTotal{Month: month, CategoryID: tx.CategoryID, Type: tx.Type, Amount: tx.Amount, Count: 1})
+	}
+	return totals, nil
+}
+
+type fakeCategoryRepository struct {
+	categories []domain.Category
+}
+
+func (r *fakeCategoryRepository) GetAllCategories(ctx context.Context) ([]domain.Category, error) {
+	return r.categories, nil
+}
+
+func (r *fakeCategoryRepository) SetCategoryParent(ctx context.Context, id int, parentID *int) error {
+	return errors.New("not implemented")
+}
+
+// receivedMail is a message the test SMTP server accepted
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPServer accepts one SMTP session on a local port and sends the
+// message it receives on the returned channel.
+func newSMTPServer(t *testing.T) (string, <-chan receivedMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan receivedMail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var message receivedMail
+		reply := func(format string, args ...any) bool {
+			return text.PrintfLine(format, args...) == nil
+		}
+		if !reply("220 localhost ESMTP test") {
+			return
+		}
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command, argument, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(command) {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				message.from = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+				reply("250 OK")
+			case "RCPT":
+				message.to = append(message.to, strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(text.DotReader())
+				if err != nil {
+					return
+				}
+				message.data = string(data)
+				reply("250 OK: queued")
+				received <- message
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestScheduledReportIsStoredAndEmailed(t *testing.T) {
+	ctx := context.Background()
+	addr, received := newSMTPServer(t)
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+	inLastMonth := lastMonth.AddDate(0, 0, 9)
+	transactions := &fakeTransactionRepository{transactions: []domain.Transaction{
+		{ID: 1, CategoryID: 1, Amount: 3000, Type: domain.Income, Date: &inLastMonth},
+		{ID: 2, CategoryID: 2, Amount: 120.5, Type: domain.Expense, Date: &inLastMonth, Description: "Groceries"},
+	}}
+	categories := &fakeCategoryRepository{categories: []domain.Category{{ID: 1, Name: "Salary"}, {ID: 2, Name: "Food"}}}
+
+	reports := &fakeReportRepository{}
+	webhookService := NewWebhookService(&fakeWebhookRepository{now: now}, external.NewWebhookSender(time.Second), 1, time.Minute)
+	budgets := &fakeBudgetRepository{budgets: []domain.Budget{{ID: 1, CategoryID: 2, Amount: 100}}}
+	budgetService := NewBudgetService(budgets, transactions, categories, webhookService, time.Minute)
+	mailer := external.NewMailer(config.SMTPConfig{Addr: addr, From: "reports@example.com", Timeout: 5 * time.Second})
+	s := NewReportService(reports, transactions, categories, budgetService, nil, nil, webhookService, mailer, ReportOptions{
+		Periods:       []domain.ReportPeriod{domain.ReportMonthly},
+		CheckInterval: time.Hour,
+		EmailTo:       []string{"home@example.com"},
+	})
+
+	s.produceDue(ctx)
+
+	if len(reports.reports) != 1 {
+		t.Fatalf("stored %d reports, want 1", len(reports.reports))
+	}
+	report := reports.reports[0]
+	if report.Period != domain.ReportMonthly || !report.PeriodStart.Equal(lastMonth) || !report.PeriodEnd.Equal(thisMonth) {
+		t.Errorf("stored the %s report of %s to %s, want the monthly one of %s to %s", report.Period, report.PeriodStart, report.PeriodEnd, lastMonth, thisMonth)
+	}
+	if report.Summary.Income != 3000 || report.Summary.Expense != 120.5 {
+		t.Errorf("stored income %.2f and expenses %.2f, want 3000 and 120.5", report.Summary.Income, report.Summary.Expense)
+	}
+	wantBudget := domain.ReportBudget{CategoryID: 2, CategoryName: "Food", Amount: 100, Spent: 120.5, Remaining: -20.5, Exceeded: true}
+	if len(report.Summary.Budgets) != 1 || report.Summary.Budgets[0] != wantBudget {
+		t.Errorf("stored budgets %+v, want %+v", report.Summary.Budgets, wantBudget)
+	}
+
+	var message receivedMail
+	select {
+	case message = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+	}
+	if message.from != "reports@example.com" || len(message.to) != 1 || message.to[0] != "home@example.com" {
+		t.Errorf("mail from %q to %q, want reports@example.com to home@example.com", message.from, message.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if got, want := parsed.Header.Get("Subject"), "Monthly report: "+lastMonth.Format("January 2006"); got != want {
+		t.Errorf("subject %q, want %q", got, want)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if string(body) != renderReport(&report) {
+		t.Errorf("body\n%s\nwant\n%s", body, renderReport(&report))
+	}
+	for _, want := range []string{"Food", "Groceries", "120.50", "Budgets", "120.50 of 100.00  exceeded by 20.50"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body does not mention %q:\n%s", want, body)
+		}
+	}
+
+	// The report is only produced once per period.
+	s.produceDue(ctx)
+	if len(reports.reports) != 1 {
+		t.Fatalf("stored %d reports after a second check, want 1", len(reports.reports))
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+)
+
+const maxTransactionTags = 20
+
+// tagNamePattern is what normalized tag names look like, such as "vacation-2026".
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// TagSpending sums the transactions carrying a tag. FirstDate and LastDate
+// are nil when none of them has a date.
+type TagSpending struct {
+	TagName          string
+	Expense          float64
+	Income           float64
+	TransactionCount int
+	FirstDate        *time.Time
+	LastDate         *time.Time
+}
+
+type TagService struct {
+	tagRepo         *repository.TagRepository
+	transactionRepo repository.TransactionRepositoryInterface
+}
+
+func NewTagService(tagRepo *repository.TagRepository, transactionRepo repository.TransactionRepositoryInterface) *TagService {
+	return &TagService{tagRepo: tagRepo, transactionRepo: transactionRepo}
+}
+
+func (s *TagService) GetTags(ctx context.Context) ([]domain.Tag, error) {
+	return s.tagRepo.GetAllTags(ctx)
+}
+
+// SetTransactionTags replaces the tags of a transaction and returns them
+// normalized, without repetitions and sorted.
+func (s *TagService) SetTransactionTags(ctx context.Context, transactionID int, names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag := domain.NormalizeTagName(name)
+		if !tagNamePattern.MatchString(tag) {
+			return nil, &ValidationError{Message: fmt.Sprintf("invalid tag %q: tags are up to 50 letters, digits, - or _, starting with a letter or digit", name)}
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTransactionTags {
+		return nil, &ValidationError{Message: fmt.Sprintf("a transaction can have at most %d tags", maxTransactionTags)}
+	}
+	sort.Strings(tags)
+
+	if err := s.tagRepo.SetTransactionTags(ctx, transactionID, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetSpendingByTag sums the transactions matching filter for each of their
+// tags, limited to the filter's tags when it has any. A transaction counts
+// towards every tag it carries. Tags are ordered by expense, largest first.
+func (s *TagService) GetSpendingByTag(ctx context.Context, filter repository.TransactionFilter) ([]TagSpending, error) {
+	ctx, span := tracing.Start(ctx, "TagService.GetSpendingByTag")
+	defer span.End()
+
+	transactions, err := s.transactionRepo.GetTransactions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	byTag := make(map[string]*TagSpending)
+	for _, tx := range transactions {
+		for _, tag := range tx.Tags {
+			if filter.Tags != nil && !slices.Contains(filter.Tags, tag) {
+				continue
+			}
+			spending, ok := byTag[tag]
+			if !ok {
+				spending = &TagSpending{TagName: tag}
+				byTag[tag] = spending
+			}
+
+			spending.TransactionCount++
+			switch tx.Type {
+			case domain.Expense:
+				spending.Expense += tx.Amount
+			case domain.Income:
+				spending.Income += tx.Amount
+			}
+			if tx.Date != nil {
+				if spending.FirstDate == nil || tx.Date.Before(*spending.FirstDate) {
+					spending.FirstDate = tx.Date
+				}
+				if spending.LastDate == nil || tx.Date.After(*spending.LastDate) {
+					spending.LastDate = tx.Date
+				}
+			}
+		}
+	}
+
+	result := make([]TagSpending, 0, len(byTag))
+	for _, spending := range byTag {
+		result = append(result, *spending)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Expense != result[j].Expense {
+			return result[i].Expense > result[j].Expense
+		}
+		return result[i].TagName < result[j].TagName
+	})
+
+	return result, nil
+}
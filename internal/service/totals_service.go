@@ -0,0 +1,206 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Dimension is something totals can be grouped by
+type Dimension string
+
+const (
+	DimensionCategory Dimension = "category"
+	DimensionType     Dimension = "type"
+	DimensionSubtype  Dimension = "subtype"
+	DimensionUser     Dimension = "user"
+)
+
+// Period is the length of the time buckets totals can be grouped into
+type Period string
+
+const (
+	PeriodDay     Period = "day"
+	PeriodWeek    Period = "week"
+	PeriodMonth   Period = "month"
+	PeriodQuarter Period = "quarter"
+	PeriodYear    Period = "year"
+)
+
+// Total is the sum of the transactions in one group. Only the fields of the
+// dimensions and period grouped by are set. The total of a category includes
+// its subcategories.
+type Total struct {
+	PeriodStart *time.Time
+	CategoryID  *int
+	Type        *domain.Type
+	Subtype     *string
+	UserID      *int
+	Amount      float64
+	Count       int
+}
+
+type TotalsService struct {
+	transactionRepo repository.TransactionRepositoryInterface
+	categoryRepo    repository.CategoryRepositoryInterface
+}
+
+func NewTotalsService(transactionRepo repository.TransactionRepositoryInterface, categoryRepo repository.CategoryRepositoryInterface) *TotalsService {
+	return &TotalsService{transactionRepo: transactionRepo, categoryRepo: categoryRepo}
+}
+
+// GetTotals sums the transactions matching filter grouped by the given
+// dimensions and, when period is not empty, by the UTC period of their date.
+// Transactions without a date are left out of period groupings. Groups are
+// ordered by period, then by amount, largest first.
+func (s *TotalsService) GetTotals(ctx context.Context, filter repository.TransactionFilter, groupBy []Dimension, period Period) ([]Total, error) {
+	ctx, span := tracing.Start(ctx, "TotalsService.GetTotals")
+	defer span.End()
+
+	for _, dimension := range groupBy {
+		switch dimension {
+		case DimensionCategory, DimensionType, DimensionSubtype, DimensionUser:
+		default:
+			return nil, &ValidationError{Message: fmt.Sprintf("unknown dimension %q", dimension)}
+		}
+	}
+	if period != "" {
+		if _, err := periodStart(time.Time{}, period); err != nil {
+			return nil, err
+		}
+	}
+
+	var lineage map[int][]int
+	if slices.Contains(groupBy, DimensionCategory) {
+		categories, err := s.categoryRepo.GetAllCategories(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch categories: %w", err)
+		}
+		lineage = domain.CategoryLineage(categories)
+	}
+
+	type groupKey struct {
+		periodStart time.Time
+		categoryID  int
+		txType      domain.Type
+		subtype     string
+		userID      int
+	}
+	groups := make(map[groupKey]*Total)
+	var order []groupKey
+
+	addTo := func(key groupKey, total Total, amount float64, count int) {
+		group, ok := groups[key]
+		if !ok {
+			group = &total
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Amount += amount
+		group.Count += count
+	}
+
+	add := func(date *time.Time, categoryID int, txType domain.Type, txSubtype *string, userID *int, amount float64, count int) {
+		var key groupKey
+		total := Total{}
+		byCategory := false
+
+		if period != "" {
+			if date == nil {
+				return
+			}
+			start, _ := periodStart(*date, period)
+			key.periodStart = start
+			total.PeriodStart = &start
+		}
+		for _, dimension := range groupBy {
+			switch dimension {
+			case DimensionCategory:
+				byCategory = true
+			case DimensionType:
+				key.txType = txType
+				total.Type = &txType
+			case DimensionSubtype:
+				if txSubtype == nil || *txSubtype == "" {
+					continue
+				}
+				key.subtype = *txSubtype
+				total.Subtype = txSubtype
+			case DimensionUser:
+				if userID == nil {
+					continue
+				}
+				key.userID = *userID
+				total.UserID = userID
+			}
+		}
+
+		if !byCategory {
+			addTo(key, total, amount, count)
+			return
+		}
+		for _, id := range rollup(lineage, categoryID) {
+			key.categoryID = id
+			total.CategoryID = &id
+			addTo(key, total, amount, count)
+		}
+	}
+
+	// Periods of whole months over whole months are summed from the monthly
+	// totals instead of every transaction.
+	if (period == PeriodMonth || period == PeriodQuarter || period == PeriodYear) && monthlyTotalsAnswer(filter) {
+		totals, err := s.transactionRepo.GetMonthlyTotals(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch monthly totals: %w", err)
+		}
+		for _, t := range totals {
+			month, userID := t.Month, t.UserID
+			add(&month, t.CategoryID, t.Type, t.Subtype, &userID, t.Amount, t.Count)
+		}
+	} else {
+		transactions, err := s.transactionRepo.GetTransactions(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		}
+		for _, tx := range transactions {
+			add(tx.Date, tx.CategoryID, tx.Type, tx.Subtype, tx.CreatedById, tx.Amount, 1)
+		}
+	}
+
+	result := make([]Total, 0, len(order))
+	for _, key := range order {
+		result = append(result, *groups[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].PeriodStart != nil && !result[i].PeriodStart.Equal(*result[j].PeriodStart) {
+			return result[i].PeriodStart.Before(*result[j].PeriodStart)
+		}
+		return result[i].Amount > result[j].Amount
+	})
+
+	return result, nil
+}
+
+// periodStart truncates t to the start of its UTC period. Weeks start on Monday.
+func periodStart(t time.Time, period Period) (time.Time, error) {
+	t = t.UTC()
+	switch period {
+	case PeriodDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	case PeriodWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	case PeriodQuarter:
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC), nil
+	case PeriodYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, &ValidationError{Message: fmt.Sprintf("unknown period %q", period)}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"analytics/internal/domain"
 	"analytics/internal/repository"
+	"analytics/internal/tracing"
 
 	"context"
 	"fmt"
@@ -31,8 +32,11 @@ func NewTransactionAnalysisService(
 	}
 }
 
-func (r *TransactionAnalysisService) GetAverageSpendByCategory(ctx context.Context) ([]AverageCategorySpendByMonth, error) {
-	transactions, err := r.transactionRepo.GetAllTransactions(ctx)
+func (r *TransactionAnalysisService) GetAverageSpendByCategory(ctx context.Context, filter repository.TransactionFilter) ([]AverageCategorySpendByMonth, error) {
+	ctx, span := tracing.Start(ctx, "TransactionAnalysisService.GetAverageSpendByCategory")
+	defer span.End()
+
+	totals, err := monthlyTotals(ctx, r.transactionRepo, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +56,7 @@ func (r *TransactionAnalysisService) GetAverageSpendByCategory(ctx context.Conte
 		Count int
 	})
 
-	processTransaction(transactions, spendByMonth)
+	processMonthlyTotals(totals, domain.CategoryLineage(categories), spendByMonth)
 
 	var result []AverageCategorySpendByMonth
 	for key, value := range spendByMonth {
@@ -74,23 +78,25 @@ func (r *TransactionAnalysisService) GetAverageSpendByCategory(ctx context.Conte
 	return result, nil
 }
 
-func processTransaction(transactions []domain.Transaction, spendByMonth map[string]struct {
+func processMonthlyTotals(totals []domain.MonthlyTotal, lineage map[int][]int, spendByMonth map[string]struct {
 	Total float64
 	Count int
 }) {
-	for _, tx := range transactions {
-		if tx.Type != domain.Expense {
+	for _, total := range totals {
+		if total.Type != domain.Expense {
 			continue
 		}
 
-		monthKey := fmt.Sprintf("%d-%d-%d",
-			tx.CategoryID,
-			tx.Date.Year(),
-			tx.Date.Month())
+		for _, categoryID := range rollup(lineage, total.CategoryID) {
+			monthKey := fmt.Sprintf("%d-%d-%d",
+				categoryID,
+				total.Month.Year(),
+				total.Month.Month())
 
-		monthly := spendByMonth[monthKey]
-		monthly.Total += tx.Amount
-		monthly.Count++
-		spendByMonth[monthKey] = monthly
+			monthly := spendByMonth[monthKey]
+			monthly.Total += total.Amount
+			monthly.Count += total.Count
+			spendByMonth[monthKey] = monthly
+		}
 	}
 }
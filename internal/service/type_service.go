@@ -2,9 +2,10 @@ package service
 
 import (
 	"analytics/internal/repository"
+	"analytics/internal/tracing"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 type AverageType struct {
@@ -20,27 +21,27 @@ func NewTypeService(transactionRepo repository.TransactionRepositoryInterface) *
 	return &TypeService{transactionRepo: transactionRepo}
 }
 
-func (r *TypeService) GetAverageByType(ctx context.Context) ([]AverageType, error) {
-	transactions, err := r.transactionRepo.GetAllTransactions(ctx)
+func (r *TypeService) GetAverageByType(ctx context.Context, filter repository.TransactionFilter) ([]AverageType, error) {
+	ctx, span := tracing.Start(ctx, "TypeService.GetAverageByType")
+	defer span.End()
+
+	totals, err := monthlyTotals(ctx, r.transactionRepo, filter)
 	if err != nil {
-		log.Printf("[TypeService.GetAverageByType] ERROR: Failed to fetch transactions: %v", err)
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		slog.ErrorContext(ctx, "Failed to fetch monthly totals", "component", "TypeService.GetAverageByType", "error", err)
+		return nil, err
 	}
 
 	// Group monthly sums by type
 	// Key: "type-year-month", Value: sum for that month
 	monthlySumsByTypeMonth := make(map[string]float64)
 
-	for _, tx := range transactions {
-		if tx.Date == nil {
-			continue
-		}
+	for _, total := range totals {
 		monthKey := fmt.Sprintf("%s-%d-%d",
-			tx.Type,
-			tx.Date.Year(),
-			tx.Date.Month())
+			total.Type,
+			total.Month.Year(),
+			total.Month.Month())
 
-		monthlySumsByTypeMonth[monthKey] += tx.Amount
+		monthlySumsByTypeMonth[monthKey] += total.Amount
 	}
 
 	// Group monthly sums by type only (to calculate average across months)
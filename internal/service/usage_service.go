@@ -0,0 +1,169 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// QuotaExceededError is returned when a client has used up one of its quotas.
+// RetryAfter is the time left until the quota period resets.
+type QuotaExceededError struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exhausted", e.Limit)
+}
+
+type UsageService struct {
+	usageRepo repository.LLMUsageRepositoryInterface
+}
+
+func NewUsageService(usageRepo repository.LLMUsageRepositoryInterface) *UsageService {
+	return &UsageService{usageRepo: usageRepo}
+}
+
+const (
+	// completionsPerRequest is how many completions a request is expected to
+	// make: a question needs one to write the SQL and one to analyze the result.
+	completionsPerRequest = 2
+	// reservationTTL bounds how long a request holds part of the quotas, so
+	// reservations left by a crashed process stop counting.
+	reservationTTL = 10 * time.Minute
+)
+
+// QuotaReservation is part of a principal's quotas held for a request in
+// flight. A nil reservation holds nothing.
+type QuotaReservation struct {
+	id int
+}
+
+// ReserveQuota returns a *QuotaExceededError when the principal has no budget
+// left for the current UTC day or month, counting what the requests in flight
+// hold. Otherwise it holds for the request what the principal's recent
+// completions used on average, so concurrent requests cannot all pass the
+// check and overshoot the quotas. Callers must release the reservation with
+// ReleaseQuota once the request has recorded its usage.
+func (s *UsageService) ReserveQuota(ctx context.Context, principal *domain.Principal) (*QuotaReservation, error) {
+	quota := principal.Quota
+	if quota.DailyTokens == nil && quota.DailyCost == nil && quota.MonthlyTokens == nil && quota.MonthlyCost == nil {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	average, err := s.usageRepo.GetAverageUsage(ctx, principal.ClientID(), monthStart)
+	if err != nil {
+		return nil, err
+	}
+	reserved := repository.UsageTotal{
+		Tokens: average.Tokens * completionsPerRequest,
+		Cost:   average.Cost * completionsPerRequest,
+	}
+
+	id, err := s.usageRepo.ReserveUsage(ctx, principal.ClientID(), []time.Time{dayStart, monthStart}, reserved, now.Add(reservationTTL), func(used []repository.UsageTotal) error {
+		day, month := used[0], used[1]
+
+		retryAfter := dayStart.AddDate(0, 0, 1).Sub(now)
+		if quota.DailyTokens != nil && day.Tokens >= *quota.DailyTokens {
+			return &QuotaExceededError{Limit: "daily token", RetryAfter: retryAfter}
+		}
+		if quota.DailyCost != nil && day.Cost >= *quota.DailyCost {
+			return &QuotaExceededError{Limit: "daily cost", RetryAfter: retryAfter}
+		}
+
+		retryAfter = monthStart.AddDate(0, 1, 0).Sub(now)
+		if quota.MonthlyTokens != nil && month.Tokens >= *quota.MonthlyTokens {
+			return &QuotaExceededError{Limit: "monthly token", RetryAfter: retryAfter}
+		}
+		if quota.MonthlyCost != nil && month.Cost >= *quota.MonthlyCost {
+			return &QuotaExceededError{Limit: "monthly cost", RetryAfter: retryAfter}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaReservation{id: id}, nil
+}
+
+// ReleaseQuota gives back what a reservation holds.
+func (s *UsageService) ReleaseQuota(ctx context.Context, reservation *QuotaReservation) error {
+	if reservation == nil {
+		return nil
+	}
+	if err := s.usageRepo.ReleaseReservation(ctx, reservation.id); err != nil {
+		slog.ErrorContext(ctx, "Failed to release quota reservation", "component", "UsageService.ReleaseQuota", "error", err)
+		return err
+	}
+	return nil
+}
+
+// RecordUsage stores what each completion made on behalf of the principal used.
+func (s *UsageService) RecordUsage(ctx context.Context, principal *domain.Principal, endpoint string, usages []external.Usage) error {
+	for _, usage := range usages {
+		record := domain.LLMUsage{
+			ClientID:         principal.ClientID(),
+			APIKeyID:         principal.KeyID,
+			Endpoint:         endpoint,
+			Model:            usage.Model,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			Cost:             usage.EstimatedCost(),
+			LatencyMs:        usage.Latency.Milliseconds(),
+		}
+		if err := s.usageRepo.CreateUsage(ctx, &record); err != nil {
+			slog.ErrorContext(ctx, "Failed to record usage", "component", "UsageService.RecordUsage", "client_id", record.ClientID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+type LLMUsageReport struct {
+	From       time.Time
+	To         time.Time
+	Total      repository.LLMUsageAggregate
+	ByDay      []repository.LLMUsageAggregate
+	ByEndpoint []repository.LLMUsageAggregate
+	ByModel    []repository.LLMUsageAggregate
+}
+
+// GetUsageReport aggregates the LLM calls made in [from, to) by day, endpoint and model.
+func (s *UsageService) GetUsageReport(ctx context.Context, from time.Time, to time.Time) (*LLMUsageReport, error) {
+	report := &LLMUsageReport{From: from, To: to}
+
+	var err error
+	if report.ByDay, err = s.usageRepo.GetUsageAggregates(ctx, "day", from, to); err != nil {
+		return nil, err
+	}
+	if report.ByEndpoint, err = s.usageRepo.GetUsageAggregates(ctx, "endpoint", from, to); err != nil {
+		return nil, err
+	}
+	if report.ByModel, err = s.usageRepo.GetUsageAggregates(ctx, "model", from, to); err != nil {
+		return nil, err
+	}
+
+	report.Total.Group = "total"
+	var latencySum float64
+	for _, day := range report.ByDay {
+		report.Total.Calls += day.Calls
+		report.Total.PromptTokens += day.PromptTokens
+		report.Total.CompletionTokens += day.CompletionTokens
+		report.Total.Cost += day.Cost
+		latencySum += day.AvgLatencyMs * float64(day.Calls)
+	}
+	if report.Total.Calls > 0 {
+		report.Total.AvgLatencyMs = latencySum / float64(report.Total.Calls)
+	}
+
+	return report, nil
+}
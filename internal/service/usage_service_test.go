@@ -0,0 +1,275 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLLMUsageRepository keeps usage and reservations in memory, checking and
+// holding reservations under one lock as the database does per client.
+type fakeLLMUsageRepository struct {
+	mu           sync.Mutex
+	usages       []domain.LLMUsage
+	reservations map[int]fakeReservation
+	nextID       int
+}
+
+type fakeReservation struct {
+	clientID string
+	held     repository.UsageTotal
+}
+
+func (r *fakeLLMUsageRepository) CreateUsage(ctx context.Context, usage *domain.LLMUsage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	usage.ID = r.nextID
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+	r.usages = append(r.usages, *usage)
+	return nil
+}
+
+func (r *fakeLLMUsageRepository) ReserveUsage(ctx context.Context, clientID string, since []time.Time, reserved repository.UsageTotal, expiresAt time.Time, check func([]repository.UsageTotal) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	totals := make([]repository.UsageTotal, len(since))
+	for i, start := range since {
+		for _, usage := range r.usages {
+			if usage.ClientID == clientID && !usage.CreatedAt.Before(start) {
+				totals[i].Tokens += usage.PromptTokens + usage.CompletionTokens
+				totals[i].Cost += usage.Cost
+			}
+		}
+		for _, reservation := range r.reservations {
+			if reservation.clientID == clientID {
+				totals[i].Tokens += reservation.held.Tokens
+				totals[i].Cost += reservation.held.Cost
+			}
+		}
+	}
+	if err := check(totals); err != nil {
+		return 0, err
+	}
+
+	if r.reservations == nil {
+		r.reservations = make(map[int]fakeReservation)
+	}
+	r.nextID++
+	r.reservations[r.nextID] = fakeReservation{clientID: clientID, held: reserved}
+	return r.nextID, nil
+}
+
+func (r *fakeLLMUsageRepository) ReleaseReservation(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.reservations, id)
+	return nil
+}
+
+func (r *fakeLLMUsageRepository) GetAverageUsage(ctx context.Context, clientID string, since time.Time) (repository.UsageTotal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sum repository.UsageTotal
+	var count int64
+	for _, usage := range r.usages {
+		if usage.ClientID == clientID && !usage.CreatedAt.Before(since) {
+			sum.Tokens += usage.PromptTokens + usage.CompletionTokens
+			sum.Cost += usage.Cost
+			count++
+		}
+	}
+	if count == 0 {
+		return repository.UsageTotal{}, nil
+	}
+	return repository.UsageTotal{Tokens: sum.Tokens / count, Cost: sum.Cost / float64(count)}, nil
+}
+
+func (r *fakeLLMUsageRepository) GetUsageAggregates(ctx context.Context, groupBy string, from time.Time, to time.Time) ([]repository.LLMUsageAggregate, error) {
+	return nil, nil
+}
+
+// held returns what the reservations of clientID hold together.
+func (r *fakeLLMUsageRepository) held(clientID string) repository.UsageTotal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total repository.UsageTotal
+	for _, reservation := range r.reservations {
+		if reservation.clientID == clientID {
+			total.Tokens += reservation.held.Tokens
+			total.Cost += reservation.held.Cost
+		}
+	}
+	return total
+}
+
+func TestReserveQuota(t *testing.T) {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	tokens := func(n int64) *int64 { return &n }
+	cost := func(c float64) *float64 { return &c }
+
+	type usage struct {
+		tokens int64
+		cost   float64
+		at     time.Time
+	}
+	tests := []struct {
+		name      string
+		quota     domain.Quota
+		used      []usage
+		inFlight  repository.UsageTotal
+		wantLimit string
+		wantHeld  repository.UsageTotal
+	}{
+		{
+			name: "no quotas hold nothing",
+			used: []usage{{tokens: 1000, at: dayStart}},
+		},
+		{
+			name:  "a first request holds nothing",
+			quota: domain.Quota{DailyTokens: tokens(100)},
+		},
+		{
+			name:     "holds twice the average completion of the month",
+			quota:    domain.Quota{DailyTokens: tokens(1000)},
+			used:     []usage{{tokens: 10, cost: 0.01, at: dayStart}, {tokens: 30, cost: 0.03, at: monthStart}},
+			wantHeld: repository.UsageTotal{Tokens: 40, Cost: 0.04},
+		},
+		{
+			name:      "daily tokens used up",
+			quota:     domain.Quota{DailyTokens: tokens(100)},
+			used:      []usage{{tokens: 100, at: dayStart}},
+			wantLimit: "daily token",
+		},
+		{
+			name:      "daily cost used up",
+			quota:     domain.Quota{DailyCost: cost(1)},
+			used:      []usage{{tokens: 10, cost: 1.5, at: dayStart}},
+			wantLimit: "daily cost",
+		},
+		{
+			name:      "monthly tokens used up",
+			quota:     domain.Quota{MonthlyTokens: tokens(100)},
+			used:      []usage{{tokens: 60, at: monthStart}, {tokens: 40, at: dayStart}},
+			wantLimit: "monthly token",
+		},
+		{
+			name:      "monthly cost used up",
+			quota:     domain.Quota{MonthlyCost: cost(2)},
+			used:      []usage{{tokens: 10, cost: 2, at: monthStart}},
+			wantLimit: "monthly cost",
+		},
+		{
+			name:     "last month does not count",
+			quota:    domain.Quota{MonthlyTokens: tokens(100)},
+			used:     []usage{{tokens: 500, at: monthStart.Add(-time.Hour)}},
+			wantHeld: repository.UsageTotal{},
+		},
+		{
+			name:      "requests in flight count",
+			quota:     domain.Quota{DailyTokens: tokens(100)},
+			used:      []usage{{tokens: 20, at: dayStart}},
+			inFlight:  repository.UsageTotal{Tokens: 80},
+			wantLimit: "daily token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			principal := &domain.Principal{Name: "test", Quota: tt.quota}
+			repo := &fakeLLMUsageRepository{}
+			for _, u := range tt.used {
+				repo.usages = append(repo.usages, domain.LLMUsage{ClientID: principal.ClientID(), PromptTokens: u.tokens, Cost: u.cost, CreatedAt: u.at})
+			}
+			if tt.inFlight != (repository.UsageTotal{}) {
+				repo.reservations = map[int]fakeReservation{-1: {clientID: principal.ClientID(), held: tt.inFlight}}
+			}
+			s := NewUsageService(repo)
+
+			reservation, err := s.ReserveQuota(ctx, principal)
+
+			var quotaErr *QuotaExceededError
+			if tt.wantLimit != "" {
+				if !errors.As(err, &quotaErr) || quotaErr.Limit != tt.wantLimit {
+					t.Fatalf("ReserveQuota error = %v, want the %s quota exhausted", err, tt.wantLimit)
+				}
+				reset := dayStart.AddDate(0, 0, 1)
+				if quotaErr.Limit == "monthly token" || quotaErr.Limit == "monthly cost" {
+					reset = monthStart.AddDate(0, 1, 0)
+				}
+				if wait := time.Until(reset); quotaErr.RetryAfter < wait-time.Minute || quotaErr.RetryAfter > wait+time.Minute {
+					t.Errorf("RetryAfter = %s, want about %s", quotaErr.RetryAfter, wait)
+				}
+				if held := repo.held(principal.ClientID()); held != tt.inFlight {
+					t.Errorf("a rejected request holds %+v", held)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReserveQuota: %v", err)
+			}
+			if held := repo.held(principal.ClientID()); held.Tokens != tt.wantHeld.Tokens || math.Abs(held.Cost-tt.wantHeld.Cost) > 1e-9 {
+				t.Errorf("held %+v, want %+v", held, tt.wantHeld)
+			}
+
+			if err := s.ReleaseQuota(ctx, reservation); err != nil {
+				t.Fatalf("ReleaseQuota: %v", err)
+			}
+			if held := repo.held(principal.ClientID()); held != (repository.UsageTotal{}) {
+				t.Errorf("still held %+v after release", held)
+			}
+		})
+	}
+}
+
+func TestConcurrentReservationsShareTheQuota(t *testing.T) {
+	ctx := context.Background()
+	limit := int64(100)
+	principal := &domain.Principal{Name: "test", Quota: domain.Quota{DailyTokens: &limit}}
+	repo := &fakeLLMUsageRepository{}
+	s := NewUsageService(repo)
+	// One completion of 30 tokens makes each request hold 60.
+	if err := s.RecordUsage(ctx, principal, "/api/v1/query", []external.Usage{{Model: "gpt", PromptTokens: 20, CompletionTokens: 10}}); err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+
+	first, err := s.ReserveQuota(ctx, principal)
+	if err != nil {
+		t.Fatalf("first ReserveQuota: %v", err)
+	}
+	// 30 used and 60 held are still below 100.
+	if _, err := s.ReserveQuota(ctx, principal); err != nil {
+		t.Fatalf("second ReserveQuota: %v", err)
+	}
+	var quotaErr *QuotaExceededError
+	if _, err := s.ReserveQuota(ctx, principal); !errors.As(err, &quotaErr) {
+		t.Fatalf("third ReserveQuota error = %v, want the daily token quota exhausted by what is held", err)
+	}
+
+	if err := s.ReleaseQuota(ctx, first); err != nil {
+		t.Fatalf("ReleaseQuota: %v", err)
+	}
+	if _, err := s.ReserveQuota(ctx, principal); err != nil {
+		t.Fatalf("ReserveQuota after a release: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"analytics/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+type UserCategorySpend struct {
+	CategoryID   int
+	CategoryName string
+	Total        float64
+}
+
+type UserSpending struct {
+	UserID           int
+	Expense          float64
+	Income           float64
+	TransactionCount int
+	Categories       []UserCategorySpend
+}
+
+type UserService struct {
+	transactionRepo repository.TransactionRepositoryInterface
+	categoryRepo    repository.CategoryRepositoryInterface
+}
+
+func NewUserService(transactionRepo repository.TransactionRepositoryInterface, categoryRepo repository.CategoryRepositoryInterface) *UserService {
+	return &UserService{transactionRepo: transactionRepo, categoryRepo: categoryRepo}
+}
+
+// GetSpendingByUser compares what each household member has entered: income and
+// expense totals per user, with the expenses broken down by category.
+func (r *UserService) GetSpendingByUser(ctx context.Context, filter repository.TransactionFilter) ([]UserSpending, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetSpendingByUser")
+	defer span.End()
+
+	transactions, err := r.transactionRepo.GetTransactions(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch transactions", "component", "UserService.GetSpendingByUser", "error", err)
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	categories, err := r.categoryRepo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryMap := make(map[int]string)
+	for _, c := range categories {
+		categoryMap[c.ID] = c.Name
+	}
+	lineage := domain.CategoryLineage(categories)
+
+	spendingByUser := make(map[int]*UserSpending)
+	expenseByUserCategory := make(map[int]map[int]float64)
+
+	for _, tx := range transactions {
+		if tx.CreatedById == nil {
+			continue
+		}
+		userID := *tx.CreatedById
+
+		spending, ok := spendingByUser[userID]
+		if !ok {
+			spending = &UserSpending{UserID: userID}
+			spendingByUser[userID] = spending
+			expenseByUserCategory[userID] = make(map[int]float64)
+		}
+
+		spending.TransactionCount++
+		switch tx.Type {
+		case domain.Expense:
+			spending.Expense += tx.Amount
+			for _, categoryID := range rollup(lineage, tx.CategoryID) {
+				expenseByUserCategory[userID][categoryID] += tx.Amount
+			}
+		case domain.Income:
+			spending.Income += tx.Amount
+		}
+	}
+
+	result := make([]UserSpending, 0, len(spendingByUser))
+	for userID, spending := range spendingByUser {
+		for categoryID, total := range expenseByUserCategory[userID] {
+			spending.Categories = append(spending.Categories, UserCategorySpend{
+				CategoryID:   categoryID,
+				CategoryName: categoryMap[categoryID],
+				Total:        total,
+			})
+		}
+		sort.Slice(spending.Categories, func(i, j int) bool {
+			return spending.Categories[i].Total > spending.Categories[j].Total
+		})
+		result = append(result, *spending)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result, nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/metrics"
+	"analytics/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// webhookSecretPrefix marks webhook signing secrets
+	webhookSecretPrefix = "whsec_"
+	// dispatchBatchSize is how many due deliveries are claimed and sent at once
+	dispatchBatchSize = 20
+	// retryBaseDelay is the wait after the first failed attempt. It doubles
+	// with every attempt up to retryMaxDelay.
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// WebhookEvent is the body posted to webhook receivers
+type WebhookEvent struct {
+	ID        string           `json:"id"`
+	Type      domain.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      any              `json:"data"`
+}
+
+type WebhookService struct {
+	webhookRepo  repository.WebhookRepositoryInterface
+	sender       *external.WebhookSender
+	maxAttempts  int
+	pollInterval time.Duration
+	wake         chan struct{}
+}
+
+// NewWebhookService builds the webhook registry and dispatcher. A delivery is
+// attempted up to maxAttempts times before it is marked failed, and due
+// retries are looked for every pollInterval.
+func NewWebhookService(webhookRepo repository.WebhookRepositoryInterface, sender *external.WebhookSender, maxAttempts int, pollInterval time.Duration) *WebhookService {
+	return &WebhookService{
+		webhookRepo:  webhookRepo,
+		sender:       sender,
+		maxAttempts:  maxAttempts,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// CreateWebhook registers a receiver for events. The generated signing
+// secret is set on the returned webhook and never returned again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, rawURL string, events []domain.EventType) (*domain.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, &ValidationError{Message: "url must be an absolute http or https URL"}
+	}
+	if len(events) == 0 {
+		return nil, &ValidationError{Message: "at least one event is required"}
+	}
+
+	var subscribed []domain.EventType
+	for _, event := range events {
+		if !slices.Contains(domain.EventTypes, event) {
+			return nil, &ValidationError{Message: fmt.Sprintf("unknown event %q", event)}
+		}
+		if !slices.Contains(subscribed, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	webhook := domain.Webhook{
+		URL:    rawURL,
+		Secret: webhookSecretPrefix + secret,
+		Events: subscribed,
+	}
+	if err := s.webhookRepo.CreateWebhook(ctx, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.webhookRepo.GetAllWebhooks(ctx)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	return s.webhookRepo.DeleteWebhook(ctx, id)
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *WebhookService) GetDeliveries(ctx context.Context, id int, limit int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetDeliveries(ctx, id, limit)
+}
+
+// Publish queues event for every webhook subscribed to it. Deliveries are
+// stored before Publish returns, so they survive a restart, and sent by Run.
+func (s *WebhookService) Publish(ctx context.Context, event domain.EventType, data any) error {
+	webhooks, err := s.webhookRepo.GetAllWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+
+	var subscribers []domain.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	_, err = s.queue(ctx, subscribers, event, data)
+	return err
+}
+
+// Ping queues a ping event for one webhook, to check its receiver.
+func (s *WebhookService) Ping(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	webhook, err := s.webhookRepo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.queue(ctx, []domain.Webhook{*webhook}, domain.EventPing, map[string]int{"webhook_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries[0], nil
+}
+
+func (s *WebhookService) queue(ctx context.Context, webhooks []domain.Webhook, event domain.EventType, data any) ([]*domain.WebhookDelivery, error) {
+	eventID, err := randomHex(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate event id: %w", err)
+	}
+	payload, err := json.Marshal(WebhookEvent{
+		ID:        "evt_" + eventID,
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, &domain.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   "evt_" + eventID,
+			EventType: event,
+			Payload:   payload,
+		})
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return deliveries, nil
+}
+
+// Run sends due deliveries until ctx is done, right away when events are
+// published and every poll interval for retries. Attempts in flight when ctx
+// is done are finished before Run returns.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *WebhookService) dispatchDue(ctx context.Context) {
+	// Claimed deliveries are leased for longer than an attempt can take, so
+	// another instance never sends them at the same time.
+	lease := s.sender.Timeout() + time.Minute
+	attemptCtx := context.WithoutCancel(ctx)
+
+	for ctx.Err() == nil {
+		due, err := s.webhookRepo.ClaimDueDeliveries(attemptCtx, dispatchBatchSize, lease)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim webhook deliveries", "component", "WebhookService.Run", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.attempt(attemptCtx, &due[i])
+			}()
+		}
+		wg.Wait()
+
+		if len(due) < dispatchBatchSize {
+			return
+		}
+	}
+}
+
+func (s *WebhookService) attempt(ctx context.Context, due *repository.DueDelivery) {
+	delivery := &due.Delivery
+	delivery.Attempts++
+
+	statusCode, err := s.sender.Send(ctx, external.WebhookRequest{
+		URL:        due.URL,
+		Secret:     due.Secret,
+		Event:      string(delivery.EventType),
+		DeliveryID: delivery.ID,
+		Payload:    delivery.Payload,
+	})
+
+	now := time.Now()
+	delivery.LastStatusCode = nil
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	delivery.LastError = nil
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.maxAttempts:
+		message := err.Error()
+		delivery.LastError = &message
+		delivery.Status = domain.DeliveryFailed
+	default:
+		message := err.Error()
+		delivery.LastError = &message
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+	}
+
+	outcome := string(delivery.Status)
+	if delivery.Status == domain.DeliveryPending {
+		outcome = "retrying"
+	}
+	metrics.WebhookAttempts.WithLabelValues(string(delivery.EventType), outcome).Inc()
+
+	logArgs := []any{"component", "WebhookService.attempt", "webhook_id", delivery.WebhookID, "delivery_id", delivery.ID,
+		"event", delivery.EventType, "attempt", delivery.Attempts, "status_code", statusCode}
+	if err != nil {
+		slog.WarnContext(ctx, "Webhook delivery attempt failed", append(logArgs, "outcome", outcome, "error", err)...)
+	} else {
+		slog.InfoContext(ctx, "Webhook delivered", logArgs...)
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to record webhook delivery", "component", "WebhookService.attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// retryDelay is how long to wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
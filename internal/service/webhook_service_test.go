@@ -0,0 +1,284 @@
+package service
+
+import (
+	"analytics/external"
+	"analytics/internal/domain"
+	"analytics/internal/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebhookRepository keeps webhooks and deliveries in memory. Deliveries
+// are due once their next attempt is not after now.
+type fakeWebhookRepository struct {
+	mu         sync.Mutex
+	now        time.Time
+	webhooks   []domain.Webhook
+	deliveries []*domain.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook.ID = len(r.webhooks) + 1
+	r.webhooks = append(r.webhooks, *webhook)
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetWebhook(ctx context.Context, id int) (*domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id {
+			return &webhook, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (r *fakeWebhookRepository) GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Webhook(nil), r.webhooks...), nil
+}
+
+func (r *fakeWebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range deliveries {
+		delivery.ID = len(r.deliveries) + 1
+		delivery.Status = domain.DeliveryPending
+		delivery.NextAttemptAt = r.now
+		stored := *delivery
+		r.deliveries = append(r.deliveries, &stored)
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repository.DueDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []repository.DueDelivery
+	for _, delivery := range r.deliveries {
+		if len(due) == limit || delivery.Status != domain.DeliveryPending || delivery.NextAttemptAt.After(r.now) {
+			continue
+		}
+		for _, webhook := range r.webhooks {
+			if webhook.ID == delivery.WebhookID {
+				due = append(due, repository.DueDelivery{Delivery: *delivery, URL: webhook.URL, Secret: webhook.Secret})
+			}
+		}
+		delivery.NextAttemptAt = r.now.Add(lease)
+	}
+	return due, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *delivery
+	r.deliveries[delivery.ID-1] = &stored
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetDeliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []domain.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, *r.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) advance(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = r.now.Add(d)
+}
+
+// receivedWebhook is a request the test receiver got
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a webhook receiver answering with the given status
+// codes in turn, and 200 once they run out.
+func newReceiver(t *testing.T, statusCodes ...int) (*httptest.Server, func() []receivedWebhook) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedWebhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(received) <= len(statusCodes) {
+			status = statusCodes[len(received)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+}
+
+func TestWebhookDeliveryIsSignedRetriedAndLogged(t *testing.T) {
+	ctx := context.Background()
+	server, received := newReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	repo := &fakeWebhookRepository{now: time.Now()}
+	s := NewWebhookService(repo, external.NewWebhookSender(5*time.Second), 5, time.Minute)
+
+	webhook, err := s.CreateWebhook(ctx, server.URL, []domain.EventType{domain.EventReportReady})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if err := s.Publish(ctx, domain.EventReportReady, map[string]int{"report_id": 7}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// The first attempt fails and is retried after the base delay.
+	start := time.Now()
+	s.dispatchDue(ctx)
+	log, _ := s.GetDeliveries(ctx, webhook.ID, 10)
+	if len(log) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(log))
+	}
+	first := log[0]
+	if first.Status != domain.DeliveryPending || first.Attempts != 1 {
+		t.Fatalf("after a 500, got status %s after %d attempts, want pending after 1", first.Status, first.Attempts)
+	}
+	if first.LastStatusCode == nil || *first.LastStatusCode != http.StatusInternalServerError || first.LastError == nil {
+		t.Fatalf("after a 500, got status code %v and error %v", first.LastStatusCode, first.LastError)
+	}
+	if delay := first.NextAttemptAt.Sub(start); delay < retryBaseDelay || delay > retryBaseDelay+time.Minute {
+		t.Fatalf("retry scheduled %s later, want about %s", delay, retryBaseDelay)
+	}
+
+	// Nothing is sent again before the retry is due.
+	s.dispatchDue(ctx)
+	if got := len(received()); got != 1 {
+		t.Fatalf("receiver got %d requests before the retry was due, want 1", got)
+	}
+
+	// The second attempt fails too and waits twice as long.
+	repo.advance(retryBaseDelay + time.Minute)
+	start = time.Now()
+	s.dispatchDue(ctx)
+	log, _ = s.GetDeliveries(ctx, webhook.ID, 10)
+	second := log[0]
+	if second.Attempts != 2 || *second.LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %d attempts and status code %d, want 2 and 503", second.Attempts, *second.LastStatusCode)
+	}
+	if delay := second.NextAttemptAt.Sub(start); delay < 2*retryBaseDelay || delay > 2*retryBaseDelay+time.Minute {
+		t.Fatalf("second retry scheduled %s later, want about %s", delay, 2*retryBaseDelay)
+	}
+
+	// The third attempt is delivered.
+	repo.advance(2*retryBaseDelay + time.Minute)
+	s.dispatchDue(ctx)
+	log, _ = s.GetDeliveries(ctx, webhook.ID, 10)
+	delivered := log[0]
+	if delivered.Status != domain.DeliverySucceeded || delivered.Attempts != 3 || delivered.DeliveredAt == nil {
+		t.Fatalf("got status %s after %d attempts, want succeeded after 3", delivered.Status, delivered.Attempts)
+	}
+	if *delivered.LastStatusCode != http.StatusOK || delivered.LastError != nil {
+		t.Fatalf("got status code %d and error %v, want 200 and none", *delivered.LastStatusCode, delivered.LastError)
+	}
+
+	requests := received()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(requests))
+	}
+	for _, request := range requests {
+		timestamp := request.header.Get(external.WebhookTimestampHeader)
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write([]byte(timestamp + "." + string(request.body)))
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if got := request.header.Get(external.WebhookSignatureHeader); got != want {
+			t.Errorf("signature %q, want %q", got, want)
+		}
+		if got := request.header.Get(external.WebhookEventHeader); got != string(domain.EventReportReady) {
+			t.Errorf("event header %q, want %q", got, domain.EventReportReady)
+		}
+		if got := request.header.Get(external.WebhookDeliveryHeader); got != strconv.Itoa(delivered.ID) {
+			t.Errorf("delivery header %q, want %d", got, delivered.ID)
+		}
+		if string(request.body) != string(requests[0].body) {
+			t.Errorf("retried with body %s, want the original %s", request.body, requests[0].body)
+		}
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(requests[0].body, &event); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if event.Type != domain.EventReportReady || event.ID != delivered.EventID {
+		t.Errorf("got event %s of type %s, want %s of type %s", event.ID, event.Type, delivered.EventID, domain.EventReportReady)
+	}
+}
+
+func TestWebhookDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	server, received := newReceiver(t, http.StatusBadGateway, http.StatusBadGateway)
+	repo := &fakeWebhookRepository{now: time.Now()}
+	s := NewWebhookService(repo, external.NewWebhookSender(5*time.Second), 2, time.Minute)
+
+	webhook, err := s.CreateWebhook(ctx, server.URL, []domain.EventType{domain.EventReportReady})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if _, err := s.Ping(ctx, webhook.ID); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	s.dispatchDue(ctx)
+	repo.advance(time.Hour)
+	s.dispatchDue(ctx)
+	repo.advance(time.Hour)
+	s.dispatchDue(ctx)
+
+	log, _ := s.GetDeliveries(ctx, webhook.ID, 10)
+	if len(log) != 1 || log[0].Status != domain.DeliveryFailed || log[0].Attempts != 2 {
+		t.Fatalf("got %+v, want one delivery failed after 2 attempts", log)
+	}
+	if got := len(received()); got != 2 {
+		t.Fatalf("receiver got %d requests, want 2", got)
+	}
+}
+
+func TestCreateWebhookRejectsUnpublishedEvents(t *testing.T) {
+	s := NewWebhookService(&fakeWebhookRepository{}, external.NewWebhookSender(time.Second), 1, time.Minute)
+
+	for _, event := range []domain.EventType{"budget.crossed", "transaction.created", domain.EventPing} {
+		_, err := s.CreateWebhook(context.Background(), "https://example.com/hook", []domain.EventType{event})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("subscribing to %q: got %v, want a validation error", event, err)
+		}
+	}
+}